@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+// newTestAdmin returns an admin working on in-memory storage
+func newTestAdmin(t *testing.T, stdin string) *admin {
+	t.Helper()
+	cfg := config.Default()
+	return &admin{
+		cfg:   cfg,
+		in:    strings.NewReader(stdin),
+		out:   &bytes.Buffer{},
+		repos: memory.New(),
+		jobs:  jobs.NewMemoryStore(),
+	}
+}
+
+func run(t *testing.T, a *admin, args ...string) string {
+	t.Helper()
+	out := a.out.(*bytes.Buffer)
+	out.Reset()
+	if err := a.run(context.Background(), args); err != nil {
+		t.Fatalf("%v: %v", args, err)
+	}
+	return out.String()
+}
+
+func TestUsers(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAdmin(t, "s3cret-password\n")
+
+	out := run(t, a, "users", "create", "Ops@Example.com", "admin")
+	if !strings.Contains(out, "ops@example.com (admin)") || !strings.Contains(out, "Password: ") {
+		t.Errorf("users create output = %q, want the account and a generated password", out)
+	}
+	password := strings.TrimSpace(out[strings.Index(out, "Password: ")+len("Password: "):])
+	user, err := a.repos.Users.GetByEmail(ctx, "ops@example.com")
+	if err != nil || user.Role != "admin" || !auth.CheckPassword(user.PasswordHash, password) {
+		t.Fatalf("stored user = %+v, %v; want an admin with the printed password", user, err)
+	}
+
+	a.passwordStdin = true
+	run(t, a, "users", "create", "dev@example.com")
+	a.passwordStdin = false
+	dev, err := a.repos.Users.GetByEmail(ctx, "dev@example.com")
+	if err != nil || dev.Role != "user" || !auth.CheckPassword(dev.PasswordHash, "s3cret-password") {
+		t.Fatalf("stored user = %+v, %v; want a user with the password from stdin", dev, err)
+	}
+
+	if err := a.run(ctx, []string{"users", "create", "DEV@example.com"}); !errors.Is(err, repository.ErrEmailTaken) {
+		t.Errorf("duplicate users create error = %v, want ErrEmailTaken", err)
+	}
+
+	run(t, a, "users", "disable", "dev@example.com")
+	if dev, _ := a.repos.Users.GetByEmail(ctx, "dev@example.com"); !dev.Disabled {
+		t.Error("users disable left the account active")
+	}
+	out = run(t, a, "users", "list")
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "active") || !strings.Contains(lines[2], "disabled") {
+		t.Errorf("users list = %q, want a header and two accounts", out)
+	}
+
+	run(t, a, "users", "enable", "dev@example.com")
+	if dev, _ := a.repos.Users.GetByEmail(ctx, "dev@example.com"); dev.Disabled {
+		t.Error("users enable left the account disabled")
+	}
+	if err := a.run(ctx, []string{"users", "disable", "nobody@example.com"}); !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("users disable of an unknown email error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestKeysRotate(t *testing.T) {
+	a := newTestAdmin(t, "")
+	a.cfg.JWTSecret = "current"
+	a.cfg.JWTPreviousSecrets = "older,oldest,ancient"
+
+	out := run(t, a, "keys", "rotate")
+	settings := map[string]string{}
+	for _, line := range strings.Split(out, "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			settings[name] = value
+		}
+	}
+	if len(settings["JWT_SECRET"]) < 32 {
+		t.Errorf("JWT_SECRET = %q, want a long random secret", settings["JWT_SECRET"])
+	}
+	if got := settings["JWT_PREVIOUS_SECRETS"]; got != "current,older,oldest" {
+		t.Errorf("JWT_PREVIOUS_SECRETS = %q, want the old secret first and the oldest dropped", got)
+	}
+
+	out = run(t, a, "keys", "list")
+	if want := auth.NewKey("current").ID + "\tsign\n"; !strings.HasPrefix(out, want) {
+		t.Errorf("keys list = %q, want it to start with %q", out, want)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	ctx := context.Background()
+	src := newTestAdmin(t, "")
+	alice := &models.User{Email: "alice@example.com", PasswordHash: "hash-a", Role: "user"}
+	bob := &models.User{Email: "bob@example.com", PasswordHash: "hash-b", Role: "admin", Disabled: true}
+	for _, u := range []*models.User{alice, bob} {
+		if err := src.repos.Users.Create(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src.repos.Messages.Create(ctx, &models.Message{UserID: alice.ID, Username: "alice", Content: "hello"})
+	src.repos.Tasks.Create(ctx, &models.Task{UserID: bob.ID, Title: "ship it", Done: true})
+
+	file := filepath.Join(t.TempDir(), "export.ndjson")
+	run(t, src, "export", file)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 4 {
+		t.Errorf("export has %d lines, want 4:\n%s", n, data)
+	}
+
+	// The target already has bob under a different ID
+	dst := newTestAdmin(t, string(data))
+	dst.repos.Users.Create(ctx, &models.User{Email: "carol@example.com", PasswordHash: "hash-c"})
+	dst.repos.Users.Create(ctx, &models.User{Email: "bob@example.com", PasswordHash: "kept"})
+	out := run(t, dst, "import")
+	if !strings.Contains(out, "Imported 1 users (skipped 1 existing), 1 messages and 1 tasks") {
+		t.Errorf("import output = %q", out)
+	}
+
+	imported, err := dst.repos.Users.GetByEmail(ctx, "alice@example.com")
+	if err != nil || imported.PasswordHash != "hash-a" {
+		t.Errorf("imported alice = %+v, %v; want her password hash kept", imported, err)
+	}
+	existing, _ := dst.repos.Users.GetByEmail(ctx, "bob@example.com")
+	if existing.PasswordHash != "kept" {
+		t.Error("import overwrote an existing account")
+	}
+	tasks, _ := dst.repos.Tasks.List(ctx, repository.TaskFilter{}, repository.ListOptions{})
+	if len(tasks) != 1 || tasks[0].UserID != existing.ID || tasks[0].Title != "ship it" || !tasks[0].Done {
+		t.Errorf("imported tasks = %+v, want bob's task attached to his existing account %d", tasks, existing.ID)
+	}
+	messages, _ := dst.repos.Messages.List(ctx, repository.ListOptions{})
+	if len(messages) != 1 || messages[0].Content != "hello" || messages[0].UserID != imported.ID {
+		t.Errorf("imported messages = %+v, want alice's message attached to her new account %d", messages, imported.ID)
+	}
+}
+
+func TestImportRejectsOrphanTasks(t *testing.T) {
+	a := newTestAdmin(t, `{"type":"task","task":{"id":3,"user_id":42,"title":"orphan"}}`+"\n")
+	err := a.run(context.Background(), []string{"import"})
+	if err == nil || !strings.Contains(err.Error(), "record 1: task 3 belongs to user 42") {
+		t.Errorf("import error = %v, want the orphan task reported", err)
+	}
+}
+
+func TestPurge(t *testing.T) {
+	ctx := context.Background()
+	a := newTestAdmin(t, "")
+	a.cfg.JobRetention = time.Nanosecond
+	for range 2 {
+		if err := a.jobs.Enqueue(ctx, &jobs.Job{Kind: "email", RunAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	claimed, _ := a.jobs.Claim(ctx, time.Now(), 1, time.Minute)
+	a.jobs.Complete(ctx, claimed[0].ID, claimed[0].Attempts)
+	time.Sleep(time.Millisecond)
+
+	if out := run(t, a, "purge"); !strings.Contains(out, "Purged 1 finished jobs") {
+		t.Errorf("purge output = %q", out)
+	}
+	if pending, _ := a.jobs.List(ctx, jobs.StatePending, repository.ListOptions{}); len(pending) != 1 {
+		t.Errorf("pending jobs after purge = %d, want 1", len(pending))
+	}
+}
+
+func TestMemoryStorageIsRefused(t *testing.T) {
+	cfg := config.Default()
+	cfg.Storage = "memory"
+	a := &admin{cfg: cfg, out: &bytes.Buffer{}}
+	for _, args := range [][]string{{"users", "list"}, {"export"}, {"health"}, {"migrations"}} {
+		if err := a.run(context.Background(), args); err == nil || !strings.Contains(err.Error(), "storage is memory") {
+			t.Errorf("%v error = %v, want storage refused", args, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// Record types in an export
+const (
+	recordUser    = "user"
+	recordMessage = "message"
+	recordTask    = "task"
+)
+
+// record is one line of an NDJSON export. Exactly one of the payload
+// fields is set, matching Type.
+type record struct {
+	Type    string          `json:"type"`
+	User    *exportedUser   `json:"user,omitempty"`
+	Message *models.Message `json:"message,omitempty"`
+	Task    *models.Task    `json:"task,omitempty"`
+}
+
+// exportedUser includes the password hash the API never returns, so
+// imported accounts keep their passwords
+type exportedUser struct {
+	models.User
+	PasswordHash string `json:"password_hash"`
+}
+
+// purge deletes finished jobs older than the configured retention, as the
+// server's nightly task does
+func (a *admin) purge(ctx context.Context) error {
+	if err := a.connect(ctx); err != nil {
+		return err
+	}
+	n, err := a.jobs.Purge(ctx, time.Now().Add(-a.cfg.JobRetention))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "✅ Purged %d finished jobs older than %s\n", n, a.cfg.JobRetention)
+	return nil
+}
+
+// export writes every user, then every message, then every task, so an
+// import sees users before the tasks that reference them
+func (a *admin) export(ctx context.Context, args []string) (err error) {
+	if len(args) > 1 {
+		return errors.New("usage: export [FILE]")
+	}
+	if err := a.connect(ctx); err != nil {
+		return err
+	}
+
+	out := a.out
+	if len(args) == 1 {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = f
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+
+	users, err := a.repos.Users.List(ctx, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := enc.Encode(record{Type: recordUser, User: &exportedUser{User: *u, PasswordHash: u.PasswordHash}}); err != nil {
+			return err
+		}
+	}
+	messages, err := a.repos.Messages.List(ctx, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, m := range messages {
+		if err := enc.Encode(record{Type: recordMessage, Message: m}); err != nil {
+			return err
+		}
+	}
+	tasks, err := a.repos.Tasks.List(ctx, repository.TaskFilter{}, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if err := enc.Encode(record{Type: recordTask, Task: t}); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(args) == 1 {
+		fmt.Fprintf(a.out, "✅ Exported %d users, %d messages and %d tasks to %s\n",
+			len(users), len(messages), len(tasks), args[0])
+	}
+	return nil
+}
+
+// importCounts tallies what an import created
+type importCounts struct {
+	users, existing, messages, tasks int
+}
+
+// importData creates the records of an export in one transaction, so a
+// failed import leaves the database unchanged and can be run again. Messages
+// and tasks are attached to the new IDs of their users; a message whose
+// author is not in the export is imported without one, and a task whose
+// user is not in the export fails the import at that line.
+func (a *admin) importData(ctx context.Context, args []string) error {
+	if len(args) > 1 {
+		return errors.New("usage: import [FILE]")
+	}
+	if err := a.connect(ctx); err != nil {
+		return err
+	}
+
+	in := a.in
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	var counts importCounts
+	err := a.inTx(ctx, func(repos *repository.Repositories) error {
+		counts = importCounts{}
+		userIDs := make(map[int64]int64)
+		dec := json.NewDecoder(in)
+		for line := 1; ; line++ {
+			var r record
+			err := dec.Decode(&r)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("record %d: %w", line, err)
+			}
+			if err := importRecord(ctx, repos, r, userIDs, &counts); err != nil {
+				return fmt.Errorf("record %d: %w", line, err)
+			}
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("%w; nothing was imported", err)
+	}
+
+	fmt.Fprintf(a.out, "✅ Imported %d users (skipped %d existing), %d messages and %d tasks\n",
+		counts.users, counts.existing, counts.messages, counts.tasks)
+	return nil
+}
+
+// importRecord creates one record. Existing users are looked up before
+// creating, because a failed insert would abort the transaction.
+func importRecord(ctx context.Context, repos *repository.Repositories, r record, userIDs map[int64]int64, counts *importCounts) error {
+	switch {
+	case r.Type == recordUser && r.User != nil:
+		existing, err := repos.Users.GetByEmail(ctx, r.User.Email)
+		if err == nil {
+			userIDs[r.User.ID] = existing.ID
+			counts.existing++
+			return nil
+		}
+		if !errors.Is(err, repository.ErrUserNotFound) {
+			return err
+		}
+		user := r.User.User
+		user.PasswordHash = r.User.PasswordHash
+		if err := repos.Users.Create(ctx, &user); err != nil {
+			return err
+		}
+		userIDs[r.User.ID] = user.ID
+		counts.users++
+	case r.Type == recordMessage && r.Message != nil:
+		msg := *r.Message
+		msg.UserID = userIDs[msg.UserID]
+		if err := repos.Messages.Create(ctx, &msg); err != nil {
+			return err
+		}
+		counts.messages++
+	case r.Type == recordTask && r.Task != nil:
+		task := *r.Task
+		userID, ok := userIDs[task.UserID]
+		if !ok {
+			return fmt.Errorf("task %d belongs to user %d, who is not in the import", task.ID, task.UserID)
+		}
+		task.UserID = userID
+		if err := repos.Tasks.Create(ctx, &task); err != nil {
+			return err
+		}
+		counts.tasks++
+	default:
+		return fmt.Errorf("unknown or empty record type %q", r.Type)
+	}
+	return nil
+}
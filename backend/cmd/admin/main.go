@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/postgres"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
+)
+
+const usage = `Usage: go run ./cmd/admin <command> [args] [flags]
+
+Commands:
+  users list                  list user accounts
+  users create EMAIL [ROLE]   create an account (role defaults to user) and print its password
+  users disable EMAIL         stop an account from signing in
+  users enable EMAIL          allow a disabled account to sign in again
+  keys list                   list the IDs of the configured JWT keys
+  keys rotate                 generate a new JWT secret and print the settings to deploy
+  purge                       delete finished jobs older than --job-retention
+  export [FILE]               write users, messages and tasks as NDJSON to FILE or stdout
+  import [FILE]               read NDJSON written by export from FILE or stdin
+  migrations                  list migrations and whether they are applied
+  health                      check the database and schema the server depends on
+
+Flags:
+  --password-stdin            read the password for users create from stdin
+
+Configuration flags are the same as the server's, e.g. --database-url and --config.
+Import runs in one transaction and creates new IDs and timestamps; users whose
+email already exists are kept and their messages and tasks attached to the
+existing account. Messages and tasks are not deduplicated, so importing the
+same file twice creates them twice.`
+
+// errUnhealthy makes the process exit non-zero after a failed health report
+var errUnhealthy = errors.New("health checks failed")
+
+func main() {
+	if version.Requested(os.Args[1:]) {
+		fmt.Println("admin", version.Get())
+		return
+	}
+
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	args, flags, passwordStdin := config.SplitArgs(os.Args[1:], "password-stdin")
+	cfg, err := config.Load(flags...)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	a := &admin{cfg: cfg, in: os.Stdin, out: os.Stdout, passwordStdin: passwordStdin}
+	if err := a.run(context.Background(), args); err != nil {
+		if !errors.Is(err, errUnhealthy) {
+			log.Printf("❌ %v", err)
+		}
+		os.Exit(1)
+	}
+}
+
+// admin runs one command against the storage described by cfg. Commands
+// that need the database connect lazily, so keys works without one.
+type admin struct {
+	cfg           *config.Config
+	in            io.Reader
+	out           io.Writer
+	passwordStdin bool
+
+	pool  *pgxpool.Pool
+	repos *repository.Repositories
+	jobs  jobs.Store
+}
+
+// run dispatches args to a command
+func (a *admin) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a command is required\n\n%s", usage)
+	}
+	defer a.close()
+
+	command, args := args[0], args[1:]
+	switch command {
+	case "users":
+		return a.users(ctx, args)
+	case "keys":
+		return a.keys(args)
+	case "purge":
+		return a.purge(ctx)
+	case "export":
+		return a.export(ctx, args)
+	case "import":
+		return a.importData(ctx, args)
+	case "migrations":
+		return a.migrations(ctx)
+	case "health":
+		return a.health(ctx)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+}
+
+// connect opens the server's storage unless it is already open. In-memory
+// storage lives inside the server process, so there is nothing to manage.
+func (a *admin) connect(ctx context.Context) error {
+	if a.repos != nil {
+		return nil
+	}
+	if a.cfg.Storage == "memory" {
+		return errors.New("storage is memory: data lives in the server process and cannot be managed from here")
+	}
+	pool, err := postgres.Connect(ctx, a.cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", a.cfg.Redacted().DatabaseURL, err)
+	}
+	a.pool = pool
+	a.repos = postgres.New(pool)
+	a.jobs = jobs.NewPostgresStore(pool)
+	return nil
+}
+
+// inTx runs fn with repositories whose writes commit together when fn
+// succeeds and are rolled back otherwise. Without a pool, as in tests, fn
+// runs on the open repositories.
+func (a *admin) inTx(ctx context.Context, fn func(repos *repository.Repositories) error) error {
+	if a.pool == nil {
+		return fn(a.repos)
+	}
+	return pgx.BeginFunc(ctx, a.pool, func(tx pgx.Tx) error {
+		return fn(postgres.New(tx))
+	})
+}
+
+func (a *admin) close() {
+	if a.pool != nil {
+		a.pool.Close()
+	}
+}
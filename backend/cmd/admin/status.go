@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+)
+
+// healthTimeout bounds each health check, as the server's registry does
+const healthTimeout = 2 * time.Second
+
+// migrator opens a migrator for the configured database and migrations
+// directory; the caller closes the driver
+func (a *admin) migrator(ctx context.Context) (*migrate.Migrator, *migrate.Postgres, error) {
+	if a.cfg.Storage == "memory" {
+		return nil, nil, errors.New("storage is memory: there is no schema to report on")
+	}
+	migrations, err := migrate.Load(os.DirFS(a.cfg.MigrationsDir))
+	if err != nil {
+		return nil, nil, err
+	}
+	driver, err := migrate.NewPostgres(ctx, a.cfg.DatabaseURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("connect to %s: %w", a.cfg.Redacted().DatabaseURL, err)
+	}
+	return migrate.New(driver, migrations, a.out), driver, nil
+}
+
+// migrations prints the same table as the migrate tool's status command
+func (a *admin) migrations(ctx context.Context) error {
+	m, driver, err := a.migrator(ctx)
+	if err != nil {
+		return err
+	}
+	defer driver.Close(ctx)
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+	return migrate.WriteStatus(a.out, statuses)
+}
+
+// health runs the readiness checks the server runs against its
+// dependencies and fails when any of them fails. An unreachable database is
+// reported as a failed check rather than an error.
+func (a *admin) health(ctx context.Context) error {
+	if a.cfg.Storage == "memory" {
+		return errors.New("storage is memory: there are no dependencies to check")
+	}
+	migrations, err := migrate.Load(os.DirFS(a.cfg.MigrationsDir))
+	if err != nil {
+		return err
+	}
+	pool, err := pgxpool.New(ctx, a.cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	checks := health.NewRegistry(healthTimeout)
+	checks.AddReadiness("database", health.PingCheck(pool))
+	checks.AddReadiness("migrations", migrate.SchemaCheck(migrations, func(ctx context.Context) ([]migrate.Record, error) {
+		return migrate.ReadApplied(ctx, pool)
+	}))
+
+	report := checks.Ready(ctx)
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CHECK\tSTATUS\tDURATION\tERROR")
+	for _, c := range report.Checks {
+		fmt.Fprintf(w, "%s\t%s\t%.1fms\t%s\n", c.Name, c.Status, c.Millis, strings.Join(strings.Fields(c.Error), " "))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if !report.Healthy() {
+		return errUnhealthy
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// users manages accounts
+func (a *admin) users(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: users list|create|disable|enable")
+	}
+	if err := a.connect(ctx); err != nil {
+		return err
+	}
+
+	switch command, args := args[0], args[1:]; command {
+	case "list":
+		return a.listUsers(ctx)
+	case "create":
+		if len(args) < 1 || len(args) > 2 {
+			return errors.New("usage: users create EMAIL [ROLE]")
+		}
+		role := "user"
+		if len(args) == 2 {
+			role = args[1]
+		}
+		return a.createUser(ctx, args[0], role)
+	case "disable", "enable":
+		if len(args) != 1 {
+			return fmt.Errorf("usage: users %s EMAIL", command)
+		}
+		return a.setDisabled(ctx, args[0], command == "disable")
+	default:
+		return fmt.Errorf("unknown users command %q", command)
+	}
+}
+
+func (a *admin) listUsers(ctx context.Context) error {
+	users, err := a.repos.Users.List(ctx, repository.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(a.out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tROLE\tSTATUS\tCREATED AT")
+	for _, u := range users {
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", u.ID, u.Email, u.Role, userStatus(u), u.CreatedAt.Format("2006-01-02 15:04:05"))
+	}
+	return w.Flush()
+}
+
+func userStatus(u *models.User) string {
+	if u.Disabled {
+		return "disabled"
+	}
+	return "active"
+}
+
+// createUser creates an account. Without --password-stdin a random password
+// is generated and printed once.
+func (a *admin) createUser(ctx context.Context, email, role string) error {
+	password, generated, err := a.password()
+	if err != nil {
+		return err
+	}
+	user, err := auth.NewUser(email, password, role)
+	if err != nil {
+		return err
+	}
+	if err := a.repos.Users.Create(ctx, user); err != nil {
+		return fmt.Errorf("create %s: %w", email, err)
+	}
+
+	fmt.Fprintf(a.out, "✅ Created user %d %s (%s)\n", user.ID, user.Email, user.Role)
+	if generated {
+		fmt.Fprintf(a.out, "Password: %s\n", password)
+	}
+	return nil
+}
+
+// password reads the first line of stdin or generates a password
+func (a *admin) password() (password string, generated bool, err error) {
+	if !a.passwordStdin {
+		password, err = randomSecret(18)
+		return password, true, err
+	}
+
+	line, err := bufio.NewReader(a.in).ReadString('\n')
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		if err != nil {
+			return "", false, fmt.Errorf("read password: %w", err)
+		}
+		return "", false, errors.New("read password: empty line")
+	}
+	return password, false, nil
+}
+
+func (a *admin) setDisabled(ctx context.Context, email string, disabled bool) error {
+	user, err := a.repos.Users.GetByEmail(ctx, email)
+	if err != nil {
+		return fmt.Errorf("%s: %w", email, err)
+	}
+	if user.Disabled == disabled {
+		fmt.Fprintf(a.out, "User %s is already %s\n", user.Email, userStatus(user))
+		return nil
+	}
+	user.Disabled = disabled
+	if err := a.repos.Users.Update(ctx, user); err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "✅ User %s is now %s\n", user.Email, userStatus(user))
+	return nil
+}
+
+// keys inspects and rotates the JWT signing keys. Keys live in the
+// configuration, so rotation prints the settings to deploy rather than
+// storing anything.
+func (a *admin) keys(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: keys list|rotate")
+	}
+	keys := auth.NewKeySet(a.cfg.JWTSecret, a.cfg.JWTPreviousSecretList()...)
+
+	switch args[0] {
+	case "list":
+		for i, id := range keys.IDs() {
+			use := "verify"
+			if i == 0 {
+				use = "sign"
+			}
+			fmt.Fprintf(a.out, "%s\t%s\n", id, use)
+		}
+		return nil
+	case "rotate":
+		secret, err := randomSecret(32)
+		if err != nil {
+			return err
+		}
+		next := keys.Rotate(secret)
+
+		// Retired secrets, newest first, bounded the way the server bounds them
+		var previous []string
+		for _, id := range keys.IDs()[1:] {
+			key, _ := keys.Lookup(id)
+			previous = append(previous, string(key.Secret))
+		}
+		fmt.Fprintf(a.out, "New signing key %s. Deploy these settings:\n\n", next.ID)
+		fmt.Fprintf(a.out, "JWT_SECRET=%s\n", secret)
+		fmt.Fprintf(a.out, "JWT_PREVIOUS_SECRETS=%s\n\n", strings.Join(previous, ","))
+		fmt.Fprintln(a.out, "A running server only picks up a new secret on SIGHUP when it reads it from the")
+		fmt.Fprintln(a.out, "config file or JWT_SECRET_FILE, and then keeps accepting tokens signed with the")
+		fmt.Fprintln(a.out, "old one. A secret set in the environment, and JWT_PREVIOUS_SECRETS, need a restart.")
+		return nil
+	default:
+		return fmt.Errorf("unknown keys command %q", args[0])
+	}
+}
+
+// randomSecret returns n random bytes encoded as URL-safe base64
+func randomSecret(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
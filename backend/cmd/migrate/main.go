@@ -1,41 +1,163 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
 
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
 )
 
+const usage = `Usage: go run cmd/migrate/main.go <command> [args] [flags]
+
+Commands:
+  up [N]        apply all or the next N pending migrations
+  down [N]      roll back the last N applied migrations (default 1)
+  goto V        migrate up or down to version V
+  status        list migrations and whether they are applied
+  force V       record version V as current without running SQL
+  create NAME   create empty up/down files for a new migration
+
+Flags:
+  --dry-run     print the SQL up, down and goto would run, or the state force would record,
+                without executing anything or creating schema_migrations
+
+Configuration flags are the same as the server's, e.g. --database-url and --migrations-dir.
+Applied migrations are checksummed; commands refuse to run when an applied file was edited.`
+
 func main() {
+	if version.Requested(os.Args[1:]) {
+		fmt.Println("migrate", version.Get())
+		return
+	}
+
 	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run cmd/migrate/main.go [up|down]")
+		log.Fatal(usage)
+	}
+
+	command := os.Args[1]
+	args, flags, dryRun := config.SplitArgs(os.Args[2:], "dry-run")
+
+	cfg, err := config.Load(flags...)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+
+	if command == "create" {
+		if len(args) != 1 {
+			log.Fatal("Usage: create NAME")
+		}
+		up, down, err := migrate.Create(cfg.MigrationsDir, args[0])
+		if err != nil {
+			log.Fatalf("Failed to create migration: %v", err)
+		}
+		fmt.Printf("✅ Created %s and %s\n", up, down)
+		return
+	}
+
+	if err := run(context.Background(), cfg, command, args, dryRun); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+}
+
+// run executes a command that needs the database
+func run(ctx context.Context, cfg *config.Config, command string, args []string, dryRun bool) error {
+	migrations, err := migrate.Load(os.DirFS(cfg.MigrationsDir))
+	if err != nil {
+		return err
+	}
+
+	driver, err := migrate.NewPostgres(ctx, cfg.DatabaseURL)
+	if err != nil {
+		return fmt.Errorf("connect to database: %w", err)
 	}
+	defer driver.Close(ctx)
 
-	cfg := config.Load()
-	direction := os.Args[1]
+	m := migrate.New(driver, migrations, os.Stdout)
+	m.SetDryRun(dryRun)
+	if dryRun {
+		fmt.Println("-- dry run: no SQL will be executed")
+	}
 
-	switch direction {
+	switch command {
 	case "up":
-		runMigrationsUp(cfg)
+		n, err := optionalCount(args, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔄 Running migrations UP against %s\n", cfg.Redacted().DatabaseURL)
+		if err := m.Up(ctx, n); err != nil {
+			return err
+		}
+		if !dryRun {
+			fmt.Println("✅ Migrations completed successfully")
+		}
 	case "down":
-		runMigrationsDown(cfg)
+		n, err := optionalCount(args, 1)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔄 Running migrations DOWN against %s\n", cfg.Redacted().DatabaseURL)
+		if err := m.Down(ctx, n); err != nil {
+			return err
+		}
+		if !dryRun {
+			fmt.Println("✅ Migrations rollback completed successfully")
+		}
+	case "goto":
+		v, err := requiredVersion(args)
+		if err != nil {
+			return err
+		}
+		if err := m.Goto(ctx, v); err != nil {
+			return err
+		}
+		if !dryRun {
+			fmt.Printf("✅ Migrated to version %d\n", v)
+		}
+	case "force":
+		v, err := requiredVersion(args)
+		if err != nil {
+			return err
+		}
+		return m.Force(ctx, v)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return migrate.WriteStatus(os.Stdout, statuses)
 	default:
-		log.Fatal("Invalid direction. Use 'up' or 'down'")
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
 	}
+	return nil
 }
 
-func runMigrationsUp(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations UP against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations completed successfully")
+func optionalCount(args []string, fallback int) (int, error) {
+	if len(args) == 0 {
+		return fallback, nil
+	}
+	if args[0] == "all" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("invalid count %q", args[0])
+	}
+	return n, nil
 }
 
-func runMigrationsDown(cfg *config.Config) {
-	fmt.Printf("🔄 Running migrations DOWN against %s\n", cfg.DatabaseURL)
-	// TODO: Implement actual migration logic
-	// This would typically use a migration library like golang-migrate
-	fmt.Println("✅ Migrations rollback completed successfully")
+func requiredVersion(args []string) (uint64, error) {
+	if len(args) != 1 {
+		return 0, fmt.Errorf("a version is required")
+	}
+	v, err := strconv.ParseUint(args[0], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid version %q", args[0])
+	}
+	return v, nil
 }
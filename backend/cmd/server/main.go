@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -10,14 +14,217 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/config"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/diagnostics"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/events"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/handlers"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/idempotency"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/lifecycle"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
 	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/postgres"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/publishing"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/traced"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/scheduler"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/tlsconfig"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/tracing"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
 )
 
+// certReloadInterval is how often TLS certificate files are checked for changes
+const certReloadInterval = 30 * time.Second
+
+// jobRetentionSchedule runs the cleanup of finished jobs nightly, in
+// SCHEDULER_TIMEZONE
+const jobRetentionSchedule = "15 3 * * *"
+
 func main() {
+	if version.Requested(os.Args[1:]) {
+		fmt.Println("server", version.Get())
+		return
+	}
+
+	if err := run(os.Args[1:]); err != nil {
+		log.Printf("❌ %v", err)
+		os.Exit(1)
+	}
+}
+
+// run starts the server and blocks until it is told to stop or fails.
+// The returned error reports startup failures and components that did not
+// stop cleanly.
+func run(args []string) error {
+	started := time.Now()
+
 	// Load configuration
-	cfg := config.Load()
+	cfg, err := config.Load(args...)
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	log.Printf("Loaded configuration: %v", cfg)
+
+	// Apply the log level now and whenever the config is reloaded
+	var logLevel slog.LevelVar
+	level, _ := cfg.SlogLevel()
+	logLevel.Set(level)
+	logger := logging.New(os.Stdout, &logLevel)
+	slog.SetDefault(logger)
+
+	// Components start in the order they are appended and stop in reverse
+	components := lifecycle.New(logger, cfg.ShutdownTimeout)
+
+	// Tracing is stopped last so spans from every other component are flushed
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName:    "sum25-go-flutter-course-backend",
+		ServiceVersion: version.Get().Version,
+		Environment:    cfg.Env,
+		Exporter:       cfg.TracingExporter,
+		Endpoint:       cfg.TracingEndpoint,
+		File:           cfg.TracingFile,
+		SampleRatio:    cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	components.Append(lifecycle.Hook{Name: "tracing", Stop: shutdownTracing})
+
+	// Liveness and readiness checks; dependencies register their own checks
+	checks := health.NewRegistry(2 * time.Second)
+
+	// Storage: Postgres, or process memory when running without a database
+	var repos *repository.Repositories
+	var jobStore jobs.Store
+	storageSystem := "postgresql"
+	switch cfg.Storage {
+	case "memory":
+		repos = memory.New()
+		jobStore = jobs.NewMemoryStore()
+		storageSystem = "memory"
+		log.Println("⚠️ Using in-memory storage, data is lost on restart")
+	default:
+		pool, err := pgxpool.New(context.Background(), cfg.DatabaseURL)
+		if err != nil {
+			return fmt.Errorf("database: %w", err)
+		}
+		components.Append(lifecycle.Hook{
+			Name:  "database",
+			Start: pool.Ping,
+			Stop: func(context.Context) error {
+				pool.Close()
+				return nil
+			},
+		})
+		repos = postgres.New(pool)
+		jobStore = jobs.NewPostgresStore(pool)
+
+		// Not ready until every migration in the directory has been applied
+		migrations, err := migrate.Load(os.DirFS(cfg.MigrationsDir))
+		if err != nil {
+			return fmt.Errorf("load migrations: %w", err)
+		}
+		checks.AddReadiness("database", health.PingCheck(pool))
+		checks.AddReadiness("migrations", migrate.SchemaCheck(migrations, func(ctx context.Context) ([]migrate.Record, error) {
+			return migrate.ReadApplied(ctx, pool)
+		}))
+	}
+	repos = traced.New(repos, storageSystem)
+
+	// Domain events: storage publishes, other components
+	// subscribe. Stopped after everything that publishes, before the database.
+	bus := events.New(logger)
+	repos = publishing.New(repos, bus)
+	subscribeAuditLog(bus, logger)
+	components.Append(lifecycle.Hook{Name: "event bus", Stop: bus.Close})
+
+	// Only the configured origins may call the API from a browser
+	cors := middleware.NewCORSPolicy(middleware.DefaultCORSOptions(cfg.CORSOriginList()))
+
+	// Authentication: tokens are signed with JWTSecret, older secrets stay valid
+	keys := auth.NewKeySet(cfg.JWTSecret, cfg.JWTPreviousSecretList()...)
+	tokens := auth.NewTokenManager(keys, auth.TokenOptions{
+		Issuer:     "sum25-go-flutter-course-backend",
+		AccessTTL:  cfg.JWTAccessTTL,
+		RefreshTTL: cfg.JWTRefreshTTL,
+	})
+	authHandler := handlers.NewAuthHandler(auth.NewService(repos.Users, tokens))
+	if cfg.Env == "development" {
+		components.Append(lifecycle.Hook{
+			Name:  "demo user",
+			Start: func(ctx context.Context) error { return seedDemoUser(ctx, repos.Users) },
+		})
+	}
+
+	// Rate limits per route group, keyed by user, API key or IP
+	limiter := ratelimit.NewMemoryStore()
+	apiLimit := ratelimit.NewPolicy("api", ratelimit.Limit{Rate: cfg.RateLimitRPS, Burst: cfg.RateLimitBurst})
+	authLimit := ratelimit.NewPolicy("auth", ratelimit.Limit{Rate: cfg.AuthRateLimitRPS, Burst: cfg.AuthRateLimitBurst})
+
+	// Responses replayed to POST retries carrying an Idempotency-Key
+	idempotencyStore := idempotency.NewMemoryStore()
+
+	// Background jobs run on a worker pool; stopped after the HTTP server so
+	// requests being drained can still enqueue, and before the database
+	queue := jobs.New(jobStore, jobs.Options{
+		Workers:      cfg.JobWorkers,
+		PollInterval: cfg.JobPollInterval,
+		MaxAttempts:  cfg.JobMaxAttempts,
+	}, logger)
+	components.Append(lifecycle.Hook{Name: "job queue", Start: queue.Start, Stop: queue.Stop})
+
+	// Periodic maintenance on cron schedules, stopped before the job queue
+	schedulerLocation, _ := cfg.SchedulerLocation()
+	tasks := scheduler.New(schedulerLocation, logger)
+	if err := tasks.Add(scheduler.Task{
+		Name:     "job retention",
+		Schedule: jobRetentionSchedule,
+		Timeout:  10 * time.Minute,
+		Run: func(ctx context.Context) error {
+			n, err := jobStore.Purge(ctx, time.Now().Add(-cfg.JobRetention))
+			if err == nil {
+				logger.Info("purged finished jobs", "count", n)
+			}
+			return err
+		},
+	}); err != nil {
+		return err
+	}
+	components.Append(lifecycle.Hook{Name: "scheduler", Start: tasks.Start, Stop: tasks.Stop})
+
+	// Prometheus metrics for the server and its components
+	serverMetrics := metrics.New()
+	if err := serverMetrics.Register(limiter, idempotencyStore, queue, bus); err != nil {
+		return fmt.Errorf("metrics: %w", err)
+	}
+
+	// Re-read configuration on SIGHUP
+	watcher := config.NewWatcher(cfg, args...)
+	watcher.Subscribe(func(change config.Change) {
+		level, _ := change.New.SlogLevel()
+		logLevel.Set(level)
+		cors.SetAllowedOrigins(change.New.CORSOriginList())
+		keys.Rotate(change.New.JWTSecret)
+		apiLimit.SetLimit(ratelimit.Limit{Rate: change.New.RateLimitRPS, Burst: change.New.RateLimitBurst})
+		authLimit.SetLimit(ratelimit.Limit{Rate: change.New.AuthRateLimitRPS, Burst: change.New.AuthRateLimitBurst})
+		log.Printf("🔄 Configuration reloaded, changed: %v", change.Changed)
+		if len(change.RestartRequired) > 0 {
+			log.Printf("⚠️ Restart required to apply: %v", change.RestartRequired)
+		}
+	})
+	components.Append(backgroundHook("config watcher", func(ctx context.Context) {
+		watcher.Watch(ctx, func(err error) {
+			log.Printf("Failed to reload configuration: %v", err)
+		})
+	}))
 
 	// Initialize Gin router
 	if cfg.Env == "production" {
@@ -25,49 +232,259 @@ func main() {
 	}
 
 	router := gin.New()
+	// gin trusts every proxy by default, which lets any client pick its
+	// ClientIP, and with it its rate limit bucket, via X-Forwarded-For
+	if err := router.SetTrustedProxies(cfg.TrustedProxyList()); err != nil {
+		return fmt.Errorf("trusted proxies: %w", err)
+	}
 
 	// Add middleware
-	router.Use(gin.Logger())
-	router.Use(gin.Recovery())
-	router.Use(middleware.CORS())
+	router.Use(middleware.Tracing())
+	router.Use(middleware.RequestLogger(logger))
+	router.Use(middleware.Metrics(serverMetrics))
+	router.Use(middleware.Compress(middleware.DefaultCompressOptions()))
+	router.Use(problem.Recovery())
+	router.Use(cors.Middleware())
+
+	router.NoRoute(problem.NotFound)
+
+	// Health check endpoints
+	router.GET("/health", handlers.HealthCheck(checks))
+	router.GET("/livez", handlers.Livez(checks))
+	router.GET("/readyz", handlers.Readyz(checks))
+	router.GET("/version", handlers.Version)
 
-	// Health check endpoint
-	router.GET("/health", handlers.HealthCheck)
+	// Prometheus scrape endpoint
+	router.GET("/metrics", gin.WrapH(serverMetrics.Handler()))
 
-	// API routes
-	api := router.Group("/api/v1")
-	{
-		api.GET("/ping", handlers.Ping)
-		// Add more routes as needed
+	// API routes, documented at /api/v1/openapi.json and /api/v1/docs
+	routes := &api{
+		tokens:      tokens,
+		limiter:     limiter,
+		apiLimit:    apiLimit,
+		authLimit:   authLimit,
+		idempotency: middleware.Idempotency(idempotencyStore, cfg.IdempotencyTTL),
+		auth:        authHandler,
+		messages:    handlers.NewMessageHandler(repos.Messages),
+		tasks:       handlers.NewTaskHandler(repos.Tasks),
 	}
+	routes.register(router)
 
-	// Create HTTP server
+	// Create HTTP server, serving HTTPS and HTTP/2 when a certificate is configured
 	server := &http.Server{
-		Addr:    ":" + cfg.Port,
-		Handler: router,
+		Addr:              ":" + cfg.Port,
+		Handler:           router,
+		ReadHeaderTimeout: 5 * time.Second,
 	}
+	if cfg.TLSEnabled() {
+		certs, err := tlsconfig.NewReloader(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		minVersion, err := tlsconfig.ParseVersion(cfg.TLSMinVersion)
+		if err != nil {
+			return err
+		}
+		server.TLSConfig = tlsconfig.New(certs, minVersion)
 
-	// Start server in a goroutine
-	go func() {
-		log.Printf("🚀 Server starting on port %s", cfg.Port)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
+		components.Append(backgroundHook("certificate reloader", func(ctx context.Context) {
+			certs.Watch(ctx, certReloadInterval, func() {
+				log.Println("🔐 TLS certificate reloaded")
+			}, func(err error) {
+				log.Printf("Failed to reload TLS certificate: %v", err)
+			})
+		}))
+	}
+	serveErr := make(chan error, 3)
+	components.Append(serverHook("http server", server, serveErr))
+
+	// The admin surface serves the scheduler status, plus the debug
+	// endpoints when enabled. It has its own listener on AdminAddr; on the
+	// public port it exists only behind the admin token.
+	var admin gin.IRouter
+	switch {
+	case cfg.AdminAddr != "":
+		engine := gin.New()
+		engine.Use(middleware.RequestLogger(logger))
+		engine.Use(problem.Recovery())
+		engine.NoRoute(problem.NotFound)
+		adminServer := &http.Server{
+			Addr:              cfg.AdminAddr,
+			Handler:           engine,
+			ReadHeaderTimeout: 5 * time.Second,
 		}
-	}()
+		components.Append(serverHook("admin server", adminServer, serveErr))
+		admin = engine
+		log.Printf("🔧 Admin endpoints listening on %s", cfg.AdminAddr)
+	case cfg.AdminToken != "":
+		admin = router
+		log.Println("⚠️ Admin endpoints are served on the public port behind the admin token")
+	}
+	if admin != nil {
+		status := admin.Group("/admin")
+		if cfg.AdminToken != "" {
+			status.Use(diagnostics.RequireToken(cfg.AdminToken))
+		}
+		status.GET("/scheduler", tasks.Handler())
+		if cfg.DebugEndpoints {
+			diagnostics.Register(admin, cfg.AdminToken, started)
+		}
+	}
 
-	// Wait for interrupt signal to gracefully shutdown the server
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
+	// Optionally redirect plain HTTP to HTTPS
+	if cfg.HTTPRedirectPort != "" {
+		redirect := &http.Server{
+			Addr:              ":" + cfg.HTTPRedirectPort,
+			Handler:           tlsconfig.RedirectHandler(cfg.Port),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		components.Append(serverHook("http redirect", redirect, serveErr))
+	}
+
+	// Stopped first: fail readiness so load balancers stop routing new
+	// requests here, keep serving for the shutdown delay, then drain
+	components.Append(lifecycle.Hook{
+		Name: "readiness",
+		Stop: func(ctx context.Context) error {
+			checks.SetShuttingDown()
+			return sleep(ctx, watcher.Current().ShutdownDelay)
+		},
+	})
+
+	if err := components.Start(context.Background()); err != nil {
+		return err
+	}
+	scheme := "http"
+	if cfg.TLSEnabled() {
+		scheme = "https"
+	}
+	log.Printf("🚀 Server %s listening on port %s (%s)", version.Get().Version, cfg.Port, scheme)
+
+	// Wait for an interrupt signal or a server failure
+	quit, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case <-quit.Done():
+		err = nil
+	case err = <-serveErr:
+		log.Printf("Server failed: %v", err)
+	}
 	log.Println("🛑 Shutting down server...")
 
-	// Give outstanding requests 10 seconds to complete
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), watcher.Current().ShutdownTimeout)
 	defer cancel()
-
-	if err := server.Shutdown(ctx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	if _, stopErr := components.Stop(ctx); stopErr != nil {
+		return errors.Join(err, stopErr)
+	}
+	if err != nil {
+		return err
 	}
 
 	log.Println("✅ Server exited")
+	return nil
+}
+
+// serverHook listens when started, so a busy port fails startup, and
+// serves in the background until Stop drains the connections. Serving
+// errors after startup are sent to failed.
+func serverHook(name string, server *http.Server, failed chan<- error) lifecycle.Hook {
+	return lifecycle.Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			ln, err := net.Listen("tcp", server.Addr)
+			if err != nil {
+				return err
+			}
+			go func() {
+				var err error
+				if server.TLSConfig != nil {
+					err = server.ServeTLS(ln, "", "")
+				} else {
+					err = server.Serve(ln)
+				}
+				if !errors.Is(err, http.ErrServerClosed) {
+					failed <- fmt.Errorf("%s: %w", name, err)
+				}
+			}()
+			return nil
+		},
+		Stop: server.Shutdown,
+	}
+}
+
+// backgroundHook runs fn in a goroutine while the server runs; Stop cancels
+// its context and waits for it to return
+func backgroundHook(name string, fn func(ctx context.Context)) lifecycle.Hook {
+	var (
+		cancel context.CancelFunc
+		done   = make(chan struct{})
+	)
+	return lifecycle.Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			var ctx context.Context
+			ctx, cancel = context.WithCancel(context.Background())
+			go func() {
+				defer close(done)
+				fn(ctx)
+			}()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// seedDemoUser creates the development account unless it already exists
+func seedDemoUser(ctx context.Context, users repository.UserRepository) error {
+	user, err := auth.NewUser("demo@example.com", "password", "user")
+	if err == nil {
+		err = users.Create(ctx, user)
+	}
+	switch {
+	case errors.Is(err, repository.ErrConflict):
+	case err != nil:
+		return err
+	default:
+		log.Println("👤 Seeded development user demo@example.com")
+	}
+	return nil
+}
+
+// subscribeAuditLog records domain events in the log, off the request path
+func subscribeAuditLog(bus *events.Bus, logger *slog.Logger) {
+	events.SubscribeAsync(bus, "audit log", func(ctx context.Context, e events.UserRegistered) error {
+		logger.InfoContext(ctx, "user registered", "user_id", e.User.ID, "role", e.User.Role)
+		return nil
+	})
+	events.SubscribeAsync(bus, "audit log", func(ctx context.Context, e events.MessageCreated) error {
+		logger.InfoContext(ctx, "message created", "message_id", e.Message.ID, "username", e.Message.Username)
+		return nil
+	})
+	events.SubscribeAsync(bus, "audit log", func(ctx context.Context, e events.TaskCompleted) error {
+		logger.InfoContext(ctx, "task completed", "task_id", e.Task.ID, "user_id", e.Task.UserID)
+		return nil
+	})
 }
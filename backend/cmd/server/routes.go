@@ -0,0 +1,122 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/handlers"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/openapi"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
+)
+
+// apiBasePath prefixes every versioned API route
+const apiBasePath = "/api/v1"
+
+// api holds what the /api/v1 routes are built from
+type api struct {
+	tokens    *auth.TokenManager
+	limiter   ratelimit.Store
+	apiLimit  *ratelimit.Policy
+	authLimit *ratelimit.Policy
+	// idempotency makes retried POST requests replay their first response
+	idempotency gin.HandlerFunc
+
+	auth     *handlers.AuthHandler
+	messages *handlers.MessageHandler
+	tasks    *handlers.TaskHandler
+}
+
+// register adds the /api/v1 routes to router and returns their OpenAPI
+// document. Routes must be registered through the openapi.Router so the
+// document stays complete; TestEveryRouteIsDocumented enforces this.
+func (a *api) register(router *gin.Engine) *openapi.Document {
+	doc := openapi.New("sum25-go-flutter-course API", version.Get().Version, apiBasePath, problem.Problem{})
+	doc.SetErrorMediaType(problem.ContentType)
+
+	// Bearer tokens are verified before rate limiting so signed-in users are
+	// limited per user rather than per IP
+	group := router.Group(apiBasePath,
+		middleware.OptionalAuth(a.tokens),
+		middleware.RateLimit(a.limiter, a.apiLimit, middleware.KeyByClient),
+		middleware.ETag())
+	group.GET("/openapi.json", doc.Handler())
+	group.GET("/docs", openapi.DocsHandler(apiBasePath+"/openapi.json"))
+
+	r := doc.Router(group).WithErrors(http.StatusTooManyRequests)
+	r.GET("/ping", openapi.Operation{
+		ID: "ping", Summary: "Check that the API responds", Tags: []string{"system"},
+		Response: handlers.PingResponse{},
+	}, handlers.Ping)
+
+	authRoutes := r.Group("/auth", middleware.RateLimit(a.limiter, a.authLimit, middleware.KeyByIP))
+	authRoutes.POST("/login", openapi.Operation{
+		ID: "login", Summary: "Exchange email and password for tokens", Tags: []string{"auth"},
+		Request: handlers.LoginRequest{}, Response: auth.TokenPair{}, Errors: []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, a.auth.Login)
+	authRoutes.POST("/refresh", openapi.Operation{
+		ID: "refreshToken", Summary: "Exchange a refresh token for new tokens", Tags: []string{"auth"},
+		Request: handlers.RefreshRequest{}, Response: auth.TokenPair{}, Errors: []int{http.StatusUnauthorized, http.StatusForbidden},
+	}, a.auth.Refresh)
+	authRoutes.POST("/logout", openapi.Operation{
+		ID: "logout", Summary: "Revoke the access token and an optional refresh token", Tags: []string{"auth"}, Auth: true,
+		Request: handlers.RefreshRequest{}, Status: http.StatusNoContent,
+	}, middleware.Auth(a.tokens), a.auth.Logout)
+
+	id := func(what string) openapi.Param { return openapi.PathParam("id", what+" ID", int64(0)) }
+	paging := []openapi.Param{
+		openapi.QueryParam("limit", "page size, 1 to 100", 0),
+		openapi.QueryParam("offset", "number of items to skip", 0),
+	}
+	idempotencyKey := openapi.HeaderParam(middleware.IdempotencyKeyHeader, "unique key making retries replay the first response", "")
+	idempotencyErrors := []int{http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity}
+
+	r.GET("/messages", openapi.Operation{
+		ID: "listMessages", Summary: "List messages, oldest first", Tags: []string{"messages"},
+		Params: paging, Response: []models.Message{},
+	}, a.messages.List)
+	r.POST("/messages", openapi.Operation{
+		ID: "createMessage", Summary: "Post a message as the caller", Tags: []string{"messages"}, Auth: true,
+		Params: []openapi.Param{idempotencyKey}, Request: models.CreateMessageRequest{}, Response: models.Message{}, Status: http.StatusCreated, Errors: idempotencyErrors,
+	}, middleware.Auth(a.tokens), a.idempotency, a.messages.Create)
+	r.GET("/messages/:id", openapi.Operation{
+		ID: "getMessage", Summary: "Get a message", Tags: []string{"messages"},
+		Params: []openapi.Param{id("message")}, Response: models.Message{}, Errors: []int{http.StatusNotFound},
+	}, a.messages.Get)
+	r.PUT("/messages/:id", openapi.Operation{
+		ID: "updateMessage", Summary: "Edit one of the caller's messages", Tags: []string{"messages"}, Auth: true,
+		Params: []openapi.Param{id("message")}, Request: models.UpdateMessageRequest{}, Response: models.Message{}, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+	}, middleware.Auth(a.tokens), a.messages.Update)
+	r.DELETE("/messages/:id", openapi.Operation{
+		ID: "deleteMessage", Summary: "Delete one of the caller's messages", Tags: []string{"messages"}, Auth: true,
+		Params: []openapi.Param{id("message")}, Status: http.StatusNoContent, Errors: []int{http.StatusForbidden, http.StatusNotFound},
+	}, middleware.Auth(a.tokens), a.messages.Delete)
+
+	taskRoutes := r.Group("/tasks", middleware.Auth(a.tokens))
+	taskRoutes.GET("", openapi.Operation{
+		ID: "listTasks", Summary: "List the caller's tasks", Tags: []string{"tasks"}, Auth: true,
+		Params: append(paging, openapi.QueryParam("done", "only tasks with this done flag", false)), Response: []models.Task{},
+	}, a.tasks.List)
+	taskRoutes.POST("", openapi.Operation{
+		ID: "createTask", Summary: "Create a task", Tags: []string{"tasks"}, Auth: true,
+		Params: []openapi.Param{idempotencyKey}, Request: models.TaskRequest{}, Response: models.Task{}, Status: http.StatusCreated, Errors: idempotencyErrors,
+	}, a.idempotency, a.tasks.Create)
+	taskRoutes.GET("/:id", openapi.Operation{
+		ID: "getTask", Summary: "Get one of the caller's tasks", Tags: []string{"tasks"}, Auth: true,
+		Params: []openapi.Param{id("task")}, Response: models.Task{}, Errors: []int{http.StatusNotFound},
+	}, a.tasks.Get)
+	taskRoutes.PUT("/:id", openapi.Operation{
+		ID: "updateTask", Summary: "Replace a task's title, description and done flag", Tags: []string{"tasks"}, Auth: true,
+		Params: []openapi.Param{id("task")}, Request: models.TaskRequest{}, Response: models.Task{}, Errors: []int{http.StatusNotFound},
+	}, a.tasks.Update)
+	taskRoutes.DELETE("/:id", openapi.Operation{
+		ID: "deleteTask", Summary: "Delete a task", Tags: []string{"tasks"}, Auth: true,
+		Params: []openapi.Param{id("task")}, Status: http.StatusNoContent, Errors: []int{http.StatusNotFound},
+	}, a.tasks.Delete)
+
+	return doc
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/handlers"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/idempotency"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/openapi"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+func newTestAPI(t *testing.T) (*gin.Engine, *openapi.Document) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	repos := memory.New()
+	tokens := auth.NewTokenManager(auth.NewKeySet("test-secret"), auth.TokenOptions{
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	a := &api{
+		tokens:      tokens,
+		limiter:     ratelimit.NewMemoryStore(),
+		apiLimit:    ratelimit.NewPolicy("api", ratelimit.Limit{}),
+		authLimit:   ratelimit.NewPolicy("auth", ratelimit.Limit{}),
+		idempotency: middleware.Idempotency(idempotency.NewMemoryStore(), time.Hour),
+		auth:        handlers.NewAuthHandler(auth.NewService(repos.Users, tokens)),
+		messages:    handlers.NewMessageHandler(repos.Messages),
+		tasks:       handlers.NewTaskHandler(repos.Tasks),
+	}
+
+	router := gin.New()
+	doc := a.register(router)
+	return router, doc
+}
+
+// TestEveryRouteIsDocumented fails when an /api/v1 route is registered
+// directly on gin instead of through the openapi.Router
+func TestEveryRouteIsDocumented(t *testing.T) {
+	router, doc := newTestAPI(t)
+
+	undocumented := map[string]bool{
+		apiBasePath + "/openapi.json": true,
+		apiBasePath + "/docs":         true,
+	}
+	for _, route := range router.Routes() {
+		if !strings.HasPrefix(route.Path, apiBasePath+"/") || undocumented[route.Path] {
+			continue
+		}
+		if !doc.Has(route.Method, route.Path) {
+			t.Errorf("%s %s is missing from the OpenAPI document", route.Method, route.Path)
+		}
+	}
+}
+
+func TestOpenAPIDocumentIsServed(t *testing.T) {
+	router, _ := newTestAPI(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, apiBasePath+"/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+
+	var spec struct {
+		OpenAPI    string                                `json:"openapi"`
+		Paths      map[string]map[string]json.RawMessage `json:"paths"`
+		Components struct {
+			Schemas map[string]json.RawMessage `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &spec); err != nil {
+		t.Fatal(err)
+	}
+	if spec.OpenAPI != "3.1.0" {
+		t.Errorf("Expected OpenAPI 3.1.0, got %q", spec.OpenAPI)
+	}
+	if _, ok := spec.Paths["/tasks/{id}"]["put"]; !ok {
+		t.Errorf("Expected PUT /tasks/{id} in paths, got %v", spec.Paths)
+	}
+	for _, name := range []string{"Task", "Message", "TokenPair", "Problem"} {
+		if _, ok := spec.Components.Schemas[name]; !ok {
+			t.Errorf("Expected schema %s", name)
+		}
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, apiBasePath+"/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"/api/v1/openapi.json"`) {
+		t.Errorf("Expected docs page referencing the document, got %d", w.Code)
+	}
+}
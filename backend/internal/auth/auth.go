@@ -0,0 +1,117 @@
+// Package auth issues and verifies JWT tokens and authenticates users
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// Common errors
+var (
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrUserDisabled       = errors.New("user is disabled")
+	ErrInvalidToken       = errors.New("invalid token")
+	ErrTokenExpired       = errors.New("token expired")
+	ErrTokenRevoked       = errors.New("token revoked")
+)
+
+// dummyHash is compared against when a login names an unknown email
+var dummyHash = sync.OnceValue(func() string {
+	hash, _ := HashPassword("not a real password")
+	return hash
+})
+
+// Service implements login, refresh and logout
+type Service struct {
+	users  UserStore
+	tokens *TokenManager
+}
+
+// NewService creates an authentication service
+func NewService(users UserStore, tokens *TokenManager) *Service {
+	return &Service{users: users, tokens: tokens}
+}
+
+// Tokens returns the token manager used by the service
+func (s *Service) Tokens() *TokenManager {
+	return s.tokens
+}
+
+// Login checks the credentials and issues a token pair
+func (s *Service) Login(ctx context.Context, email, password string) (*TokenPair, error) {
+	user, err := s.users.GetByEmail(ctx, email)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Spend the same time as a wrong password so response timing does
+		// not reveal which emails have accounts
+		CheckPassword(dummyHash(), password)
+		return nil, ErrInvalidCredentials
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !CheckPassword(user.PasswordHash, password) {
+		return nil, ErrInvalidCredentials
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+	return s.tokens.Issue(user)
+}
+
+// Refresh exchanges a refresh token for a new pair and revokes the old refresh token
+func (s *Service) Refresh(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	claims, err := s.tokens.Parse(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.GetByID(ctx, claims.UserID())
+	if errors.Is(err, repository.ErrNotFound) {
+		return nil, ErrInvalidToken
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, ErrUserDisabled
+	}
+
+	// Only one of several concurrent refreshes with the same token wins
+	if err := s.tokens.Revoke(claims); err != nil {
+		return nil, err
+	}
+	return s.tokens.Issue(user)
+}
+
+// Logout revokes the access token and, when given, the refresh token
+func (s *Service) Logout(access *Claims, refreshToken string) error {
+	if refreshToken != "" {
+		claims, err := s.tokens.Parse(refreshToken, TokenTypeRefresh)
+		if err != nil {
+			return err
+		}
+		if claims.Subject != access.Subject {
+			return ErrInvalidToken
+		}
+		if err := s.tokens.Revoke(claims); err != nil {
+			return err
+		}
+	}
+	return s.tokens.Revoke(access)
+}
+
+type claimsKey struct{}
+
+// NewContext returns a copy of ctx carrying the authenticated claims
+func NewContext(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, claimsKey{}, claims)
+}
+
+// FromContext returns the authenticated claims stored in ctx
+func FromContext(ctx context.Context) (*Claims, bool) {
+	claims, ok := ctx.Value(claimsKey{}).(*Claims)
+	return claims, ok
+}
@@ -0,0 +1,226 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+func newTestService(t *testing.T) (*Service, *memory.UserRepository) {
+	t.Helper()
+	users := memory.NewUserRepository()
+	user, err := NewUser("alice@example.com", "correct-horse", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	tokens := NewTokenManager(NewKeySet("test-secret"), TokenOptions{
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	return NewService(users, tokens), users
+}
+
+func TestLoginAndParse(t *testing.T) {
+	service, _ := newTestService(t)
+
+	if _, err := service.Login(context.Background(), "alice@example.com", "wrong"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials, got %v", err)
+	}
+	if _, err := service.Login(context.Background(), "bob@example.com", "correct-horse"); !errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("Expected ErrInvalidCredentials for unknown user, got %v", err)
+	}
+
+	pair, err := service.Login(context.Background(), "Alice@Example.com", "correct-horse")
+	if err != nil {
+		t.Fatalf("Expected login to succeed, got %v", err)
+	}
+
+	claims, err := service.Tokens().Parse(pair.AccessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatalf("Expected access token to parse, got %v", err)
+	}
+	if claims.UserID() != 1 || claims.Email != "alice@example.com" {
+		t.Errorf("Expected claims for user 1, got %+v", claims)
+	}
+
+	if _, err := service.Tokens().Parse(pair.RefreshToken, TokenTypeAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected refresh token to be rejected as access token, got %v", err)
+	}
+}
+
+func TestRefreshRotatesRefreshToken(t *testing.T) {
+	service, _ := newTestService(t)
+
+	pair, err := service.Login(context.Background(), "alice@example.com", "correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	next, err := service.Refresh(context.Background(), pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("Expected refresh to succeed, got %v", err)
+	}
+	if next.RefreshToken == pair.RefreshToken {
+		t.Error("Expected a new refresh token")
+	}
+
+	if _, err := service.Refresh(context.Background(), pair.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected reused refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestConcurrentRefreshSucceedsOnce(t *testing.T) {
+	service, _ := newTestService(t)
+
+	pair, err := service.Login(context.Background(), "alice@example.com", "correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const attempts = 10
+	var wg sync.WaitGroup
+	var succeeded atomic.Int32
+	for range attempts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Refresh(context.Background(), pair.RefreshToken)
+			switch {
+			case err == nil:
+				succeeded.Add(1)
+			case !errors.Is(err, ErrTokenRevoked):
+				t.Errorf("Expected ErrTokenRevoked for a losing refresh, got %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := succeeded.Load(); n != 1 {
+		t.Errorf("Expected exactly one refresh to succeed, got %d", n)
+	}
+}
+
+func TestDisabledUser(t *testing.T) {
+	service, users := newTestService(t)
+
+	pair, err := service.Login(context.Background(), "alice@example.com", "correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user, err := users.GetByEmail(context.Background(), "alice@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user.Disabled = true
+	if err := users.Update(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := service.Login(context.Background(), "alice@example.com", "correct-horse"); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("Expected ErrUserDisabled on login, got %v", err)
+	}
+	if _, err := service.Refresh(context.Background(), pair.RefreshToken); !errors.Is(err, ErrUserDisabled) {
+		t.Errorf("Expected ErrUserDisabled on refresh, got %v", err)
+	}
+}
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	service, _ := newTestService(t)
+
+	pair, err := service.Login(context.Background(), "alice@example.com", "correct-horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	access, err := service.Tokens().Parse(pair.AccessToken, TokenTypeAccess)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := service.Logout(access, pair.RefreshToken); err != nil {
+		t.Fatalf("Expected logout to succeed, got %v", err)
+	}
+	if _, err := service.Tokens().Parse(pair.AccessToken, TokenTypeAccess); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected access token to be revoked, got %v", err)
+	}
+	if _, err := service.Refresh(context.Background(), pair.RefreshToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Errorf("Expected refresh token to be revoked, got %v", err)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	service, _ := newTestService(t)
+	tokens := service.Tokens()
+
+	pair, err := tokens.Issue(&models.User{ID: 1, Email: "alice@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tokens.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	if _, err := tokens.Parse(pair.AccessToken, TokenTypeAccess); !errors.Is(err, ErrTokenExpired) {
+		t.Errorf("Expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	keys := NewKeySet("first-secret")
+	tokens := NewTokenManager(keys, TokenOptions{Issuer: "test", AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	user := &models.User{ID: 7, Email: "carol@example.com"}
+
+	old, err := tokens.Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	oldKey := keys.Signing()
+	keys.Rotate("second-secret")
+	if keys.Signing().ID == oldKey.ID {
+		t.Fatal("Expected a new signing key after rotation")
+	}
+
+	fresh, err := tokens.Issue(user)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the old and new keys verify tokens
+	for _, token := range []string{old.AccessToken, fresh.AccessToken} {
+		if _, err := tokens.Parse(token, TokenTypeAccess); err != nil {
+			t.Errorf("Expected token to verify after rotation, got %v", err)
+		}
+	}
+
+	// Retired keys no longer verify
+	keys.Retire(oldKey.ID)
+	if _, err := tokens.Parse(old.AccessToken, TokenTypeAccess); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Expected token signed with retired key to fail, got %v", err)
+	}
+	if _, err := tokens.Parse(fresh.AccessToken, TokenTypeAccess); err != nil {
+		t.Errorf("Expected current key to keep working, got %v", err)
+	}
+}
+
+func TestKeySetKeepsBoundedHistory(t *testing.T) {
+	keys := NewKeySet("k0")
+	for _, secret := range []string{"k1", "k2", "k3", "k4", "k5"} {
+		keys.Rotate(secret)
+	}
+
+	if ids := keys.IDs(); len(ids) != maxPreviousKeys+1 {
+		t.Errorf("Expected %d active keys, got %d", maxPreviousKeys+1, len(ids))
+	}
+	if _, ok := keys.Lookup(NewKey("k0").ID); ok {
+		t.Error("Expected oldest key to be dropped")
+	}
+}
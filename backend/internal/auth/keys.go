@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// maxPreviousKeys bounds how many retired keys stay valid for verification
+const maxPreviousKeys = 3
+
+// Key is an HMAC signing key identified by a key ID derived from the secret
+type Key struct {
+	ID     string
+	Secret []byte
+}
+
+// NewKey derives a stable key ID from the secret so restarts keep the same IDs
+func NewKey(secret string) Key {
+	sum := sha256.Sum256([]byte(secret))
+	return Key{ID: hex.EncodeToString(sum[:8]), Secret: []byte(secret)}
+}
+
+// KeySet holds the key new tokens are signed with and older keys that are
+// still accepted, so tokens issued before a rotation keep working until they expire
+type KeySet struct {
+	mu       sync.RWMutex
+	current  Key
+	previous []Key
+}
+
+// NewKeySet creates a key set signing with current and accepting the previous secrets
+func NewKeySet(current string, previous ...string) *KeySet {
+	ks := &KeySet{current: NewKey(current)}
+	for _, secret := range previous {
+		if secret != "" && secret != current {
+			ks.previous = append(ks.previous, NewKey(secret))
+		}
+	}
+	return ks
+}
+
+// Rotate makes secret the signing key and keeps the old one for verification.
+// Rotating to the current secret is a no-op.
+func (ks *KeySet) Rotate(secret string) Key {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	next := NewKey(secret)
+	if next.ID == ks.current.ID {
+		return next
+	}
+
+	previous := []Key{ks.current}
+	for _, k := range ks.previous {
+		if k.ID != next.ID && len(previous) < maxPreviousKeys {
+			previous = append(previous, k)
+		}
+	}
+	ks.current = next
+	ks.previous = previous
+	return next
+}
+
+// Retire stops accepting tokens signed with the given previous key
+func (ks *KeySet) Retire(id string) {
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+
+	kept := ks.previous[:0]
+	for _, k := range ks.previous {
+		if k.ID != id {
+			kept = append(kept, k)
+		}
+	}
+	ks.previous = kept
+}
+
+// Signing returns the key new tokens are signed with
+func (ks *KeySet) Signing() Key {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+	return ks.current
+}
+
+// Lookup finds an active key by ID
+func (ks *KeySet) Lookup(id string) (Key, bool) {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	if ks.current.ID == id {
+		return ks.current, true
+	}
+	for _, k := range ks.previous {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return Key{}, false
+}
+
+// IDs lists the active key IDs, signing key first
+func (ks *KeySet) IDs() []string {
+	ks.mu.RLock()
+	defer ks.mu.RUnlock()
+
+	ids := []string{ks.current.ID}
+	for _, k := range ks.previous {
+		ids = append(ids, k.ID)
+	}
+	return ids
+}
@@ -0,0 +1,190 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+)
+
+// Token types carried in the "typ" claim
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
+)
+
+// Claims are the JWT claims issued by the server
+type Claims struct {
+	jwt.RegisteredClaims
+	Email string `json:"email"`
+	Role  string `json:"role,omitempty"`
+	Type  string `json:"typ"`
+}
+
+// UserID returns the numeric user ID stored in the subject claim
+func (c *Claims) UserID() int64 {
+	id, _ := strconv.ParseInt(c.Subject, 10, 64)
+	return id
+}
+
+// TokenPair is returned by login and refresh
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// TokenOptions configures token lifetimes
+type TokenOptions struct {
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+// TokenManager issues, verifies and revokes tokens
+type TokenManager struct {
+	keys *KeySet
+	opts TokenOptions
+	now  func() time.Time
+
+	mu      sync.Mutex
+	revoked map[string]time.Time
+}
+
+// NewTokenManager creates a token manager signing with keys
+func NewTokenManager(keys *KeySet, opts TokenOptions) *TokenManager {
+	return &TokenManager{
+		keys:    keys,
+		opts:    opts,
+		now:     time.Now,
+		revoked: make(map[string]time.Time),
+	}
+}
+
+// Keys returns the key set used for signing and verification
+func (m *TokenManager) Keys() *KeySet {
+	return m.keys
+}
+
+// Issue creates a new access and refresh token pair for user
+func (m *TokenManager) Issue(user *models.User) (*TokenPair, error) {
+	access, err := m.sign(user, TokenTypeAccess, m.opts.AccessTTL)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := m.sign(user, TokenTypeRefresh, m.opts.RefreshTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(m.opts.AccessTTL.Seconds()),
+	}, nil
+}
+
+func (m *TokenManager) sign(user *models.User, typ string, ttl time.Duration) (string, error) {
+	id, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := m.now()
+	claims := &Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Issuer:    m.opts.Issuer,
+			Subject:   strconv.FormatInt(user.ID, 10),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+		Email: user.Email,
+		Role:  user.Role,
+		Type:  typ,
+	}
+
+	key := m.keys.Signing()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.Secret)
+}
+
+// Parse verifies a token of the wanted type and returns its claims
+func (m *TokenManager) Parse(raw, wantType string) (*Claims, error) {
+	claims := &Claims{}
+	_, err := jwt.ParseWithClaims(raw, claims, func(t *jwt.Token) (any, error) {
+		kid, _ := t.Header["kid"].(string)
+		key, ok := m.keys.Lookup(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown key %q", kid)
+		}
+		return key.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(m.opts.Issuer),
+		jwt.WithTimeFunc(m.now),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if claims.Type != wantType {
+		return nil, fmt.Errorf("%w: expected %s token", ErrInvalidToken, wantType)
+	}
+	if m.isRevoked(claims.ID) {
+		return nil, ErrTokenRevoked
+	}
+	return claims, nil
+}
+
+// Revoke rejects the token until it would have expired anyway. Checking and
+// revoking is one step, so of two concurrent calls for the same token only
+// one succeeds; the other gets ErrTokenRevoked.
+func (m *TokenManager) Revoke(claims *Claims) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for id, exp := range m.revoked {
+		if exp.Before(now) {
+			delete(m.revoked, id)
+		}
+	}
+	if _, ok := m.revoked[claims.ID]; ok {
+		return ErrTokenRevoked
+	}
+	if claims.ExpiresAt != nil {
+		m.revoked[claims.ID] = claims.ExpiresAt.Time
+	}
+	return nil
+}
+
+func (m *TokenManager) isRevoked(id string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.revoked[id]
+	return ok
+}
+
+// newTokenID returns a random token ID for the jti claim
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
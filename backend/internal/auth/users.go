@@ -0,0 +1,41 @@
+package auth
+
+import (
+	"context"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+)
+
+// UserStore looks up accounts for authentication. It is satisfied by
+// repository.UserRepository and must return repository.ErrNotFound for
+// unknown users.
+type UserStore interface {
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+}
+
+// NewUser returns an unsaved user with a hashed password
+func NewUser(email, password, role string) (*models.User, error) {
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	return &models.User{Email: strings.ToLower(email), PasswordHash: hash, Role: role}, nil
+}
+
+// HashPassword hashes a plain-text password with bcrypt
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword reports whether password matches the bcrypt hash
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -1,13 +1,23 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoad(t *testing.T) {
 	// Test default values
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
 	if cfg.Env != "development" {
 		t.Errorf("Expected default env to be 'development', got '%s'", cfg.Env)
@@ -35,7 +45,10 @@ func TestLoadWithEnvVars(t *testing.T) {
 		os.Unsetenv("JWT_SECRET")
 	}()
 
-	cfg := Load()
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
 
 	if cfg.Env != "test" {
 		t.Errorf("Expected env to be 'test', got '%s'", cfg.Env)
@@ -83,3 +96,203 @@ func TestGetEnvAsBool(t *testing.T) {
 		t.Errorf("Expected fallback value false, got %v", result)
 	}
 }
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	data := "port: \"7000\"\njwt_secret: file-secret\ncors_origins: https://file.example\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("JWT_SECRET", "env-secret")
+	t.Setenv("CORS_ORIGINS", "https://env.example")
+
+	cfg, err := Load("--config", path, "--cors-origins=https://flag.example")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// File overrides defaults
+	if cfg.Port != "7000" {
+		t.Errorf("Expected port from file '7000', got '%s'", cfg.Port)
+	}
+	// Env overrides file
+	if cfg.JWTSecret != "env-secret" {
+		t.Errorf("Expected JWT secret from env 'env-secret', got '%s'", cfg.JWTSecret)
+	}
+	// Flags override env
+	if cfg.CORSOrigins != "https://flag.example" {
+		t.Errorf("Expected CORS origins from flag 'https://flag.example', got '%s'", cfg.CORSOrigins)
+	}
+	// Untouched values keep their defaults
+	if cfg.Env != "development" {
+		t.Errorf("Expected default env 'development', got '%s'", cfg.Env)
+	}
+}
+
+func TestLoadTOMLFromEnv(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.toml")
+	if err := os.WriteFile(path, []byte("env = \"staging\"\nport = \"9100\"\njwt_access_ttl = \"5m\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.Env != "staging" || cfg.Port != "9100" {
+		t.Errorf("Expected staging on port 9100, got %s on %s", cfg.Env, cfg.Port)
+	}
+	if cfg.JWTAccessTTL != 5*time.Minute {
+		t.Errorf("Expected access TTL 5m from file, got %v", cfg.JWTAccessTTL)
+	}
+}
+
+func TestLoadValidationErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		args  []string
+		field string
+		want  error
+	}{
+		{"bad port", []string{"--port", "http"}, "PORT", ErrInvalidPort},
+		{"port out of range", []string{"--port", "70000"}, "PORT", ErrInvalidPort},
+		{"malformed database URL", []string{"--database-url", "localhost:5432"}, "DATABASE_URL", ErrInvalidDatabaseURL},
+		{"empty secret", []string{"--jwt-secret="}, "JWT_SECRET", ErrEmptySecret},
+		{"unknown env", []string{"--env", "prod"}, "ENV", ErrInvalidEnv},
+		{"negative TTL", []string{"--jwt-access-ttl", "-1m"}, "JWT_ACCESS_TTL", ErrInvalidDuration},
+		{"zero idempotency TTL", []string{"--idempotency-ttl", "0s"}, "IDEMPOTENCY_TTL", ErrInvalidDuration},
+		{"no job workers", []string{"--job-workers", "0"}, "JOB_WORKERS", ErrInvalidCount},
+		{"zero job poll interval", []string{"--job-poll-interval", "0s"}, "JOB_POLL_INTERVAL", ErrInvalidDuration},
+		{"no job attempts", []string{"--job-max-attempts", "0"}, "JOB_MAX_ATTEMPTS", ErrInvalidCount},
+		{"zero job retention", []string{"--job-retention", "0s"}, "JOB_RETENTION", ErrInvalidDuration},
+		{"unknown time zone", []string{"--scheduler-timezone", "Mars/Olympus"}, "SCHEDULER_TIMEZONE", ErrInvalidTimezone},
+		{"zero shutdown timeout", []string{"--shutdown-timeout", "0s"}, "SHUTDOWN_TIMEOUT", ErrInvalidDuration},
+		{"shutdown delay too long", []string{"--shutdown-delay", "10s"}, "SHUTDOWN_DELAY", ErrShutdownDelay},
+		{"TLS key without certificate", []string{"--tls-key-file", "key.pem"}, "TLS_KEY_FILE", ErrTLSIncomplete},
+		{"old TLS version", []string{"--tls-min-version", "1.0"}, "TLS_MIN_VERSION", ErrInvalidTLSVersion},
+		{"redirect without TLS", []string{"--http-redirect-port", "8081"}, "HTTP_REDIRECT_PORT", ErrRedirectWithoutTLS},
+		{"unknown tracing exporter", []string{"--tracing-exporter", "jaeger"}, "TRACING_EXPORTER", ErrInvalidExporter},
+		{"otlp-file without file", []string{"--tracing-exporter", "otlp-file"}, "TRACING_FILE", ErrTracingFile},
+		{"sample ratio above 1", []string{"--tracing-sample-ratio", "1.5"}, "TRACING_SAMPLE_RATIO", ErrInvalidSampleRatio},
+		{"admin port is the public port", []string{"--admin-addr", ":8080"}, "ADMIN_ADDR", ErrInvalidAdminAddr},
+		{"admin address without port", []string{"--admin-addr", "localhost"}, "ADMIN_ADDR", ErrInvalidAdminAddr},
+		{"public debug without token", []string{"--debug-endpoints", "--admin-addr="}, "ADMIN_TOKEN", ErrDebugUnguarded},
+		{"admin on all interfaces without token", []string{"--admin-addr", ":6060"}, "ADMIN_TOKEN", ErrAdminUnguarded},
+		{"admin on a public IP without token", []string{"--admin-addr", "203.0.113.5:6060"}, "ADMIN_TOKEN", ErrAdminUnguarded},
+		{"unknown storage", []string{"--storage", "sqlite"}, "STORAGE", ErrInvalidStorage},
+		{"malformed trusted proxy", []string{"--trusted-proxies", "10.0.0.0/8,proxy.internal"}, "TRUSTED_PROXIES", ErrInvalidProxy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load(tt.args...)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			var fe *FieldError
+			if !errors.As(err, &fe) || fe.Field != tt.field {
+				t.Errorf("Expected FieldError for %s, got %v", tt.field, err)
+			}
+		})
+	}
+}
+
+func TestLoadUnsupportedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.ini")
+	if err := os.WriteFile(path, []byte("port=1"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Load("--config=" + path); err == nil {
+		t.Error("Expected error for unsupported config file type")
+	}
+}
+
+func TestLoadProductionRefusesDefaults(t *testing.T) {
+	t.Setenv("ENV", "production")
+
+	_, err := Load()
+	if !errors.Is(err, ErrInsecureDefault) {
+		t.Fatalf("Expected ErrInsecureDefault, got %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", "short")
+	t.Setenv("DATABASE_URL", "postgres://app:s3cret-pass@db:5432/app")
+	_, err = Load()
+	if !errors.Is(err, ErrWeakSecret) {
+		t.Fatalf("Expected ErrWeakSecret, got %v", err)
+	}
+	if errors.Is(err, ErrInsecureDefault) {
+		t.Errorf("Expected custom database URL to be accepted, got %v", err)
+	}
+
+	t.Setenv("JWT_SECRET", strings.Repeat("k", minProductionSecretLen))
+	if _, err := Load(); err != nil {
+		t.Errorf("Expected strong production config to load, got %v", err)
+	}
+
+	if _, err := Load("--storage", "memory"); !errors.Is(err, ErrVolatileStorage) {
+		t.Errorf("Expected ErrVolatileStorage, got %v", err)
+	}
+}
+
+func TestLoadSecretFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jwt_secret")
+	if err := os.WriteFile(path, []byte("file-backed-secret\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("JWT_SECRET_FILE", path)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if cfg.JWTSecret != "file-backed-secret" {
+		t.Errorf("Expected JWT secret from file, got '%s'", cfg.JWTSecret)
+	}
+
+	t.Setenv("JWT_SECRET", "env-secret")
+	if _, err := Load(); err == nil {
+		t.Error("Expected error when both JWT_SECRET and JWT_SECRET_FILE are set")
+	}
+}
+
+func TestConfigRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.JWTSecret = "super-secret-value"
+	cfg.AdminToken = "super-secret-value"
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("loaded", "config", cfg)
+
+	outputs := []string{
+		fmt.Sprint(cfg),
+		fmt.Sprintf("%+v", cfg),
+		fmt.Sprintf("%#v", cfg),
+		fmt.Sprintf("%v", *cfg),
+		buf.String(),
+	}
+	for _, out := range outputs {
+		if strings.Contains(out, "super-secret-value") || strings.Contains(out, "coursepass") {
+			t.Errorf("Expected secrets to be redacted, got %s", out)
+		}
+		if !strings.Contains(out, "localhost:5432") {
+			t.Errorf("Expected non-secret values to be kept, got %s", out)
+		}
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	positional, flags, set := SplitArgs([]string{"users", "create", "a@example.com", "--password-stdin", "--database-url", "postgres://db"}, "password-stdin")
+	if strings.Join(positional, " ") != "users create a@example.com" || strings.Join(flags, " ") != "--database-url postgres://db" || !set {
+		t.Errorf("SplitArgs() = %v, %v, %v", positional, flags, set)
+	}
+	if _, _, set := SplitArgs([]string{"up", "-port", "9000"}, "dry-run"); set {
+		t.Error("SplitArgs() reported a switch that was not given")
+	}
+}
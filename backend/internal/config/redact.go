@@ -0,0 +1,63 @@
+package config
+
+import (
+	"fmt"
+	"log/slog"
+	"net/url"
+	"reflect"
+	"strings"
+)
+
+// redacted replaces secret values whenever the configuration is printed
+const redacted = "[REDACTED]"
+
+// plainConfig has the fields of Config without its formatting methods
+type plainConfig Config
+
+// Redacted returns a copy of the configuration with secrets masked
+func (c Config) Redacted() Config {
+	if c.JWTSecret != "" {
+		c.JWTSecret = redacted
+	}
+	if c.JWTPreviousSecrets != "" {
+		c.JWTPreviousSecrets = redacted
+	}
+	if c.AdminToken != "" {
+		c.AdminToken = redacted
+	}
+	c.DatabaseURL = redactURL(c.DatabaseURL)
+	return c
+}
+
+// String implements fmt.Stringer so %v and %s never print secrets
+func (c Config) String() string {
+	return fmt.Sprintf("%+v", plainConfig(c.Redacted()))
+}
+
+// GoString implements fmt.GoStringer so %#v never prints secrets
+func (c Config) GoString() string {
+	return "config.Config" + c.String()
+}
+
+// LogValue implements slog.LogValuer so structured logs never contain secrets
+func (c Config) LogValue() slog.Value {
+	r := reflect.ValueOf(c.Redacted())
+	attrs := make([]slog.Attr, 0, r.NumField())
+	for i := 0; i < r.NumField(); i++ {
+		name, _, _ := strings.Cut(r.Type().Field(i).Tag.Get("yaml"), ",")
+		attrs = append(attrs, slog.Any(name, r.Field(i).Interface()))
+	}
+	return slog.GroupValue(attrs...)
+}
+
+// redactURL masks the password of a connection URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return redacted
+	}
+	if _, hasPassword := u.User.Password(); hasPassword {
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+	}
+	return u.String()
+}
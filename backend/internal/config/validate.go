@@ -0,0 +1,305 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Validation errors wrapped by FieldError
+var (
+	ErrInvalidEnv         = errors.New("unknown environment")
+	ErrInvalidPort        = errors.New("port must be a number between 1 and 65535")
+	ErrInvalidDatabaseURL = errors.New("database URL must be a postgres:// URL with a host")
+	ErrInvalidStorage     = errors.New("storage must be postgres or memory")
+	ErrVolatileStorage    = errors.New("in-memory storage loses all data on restart")
+	ErrEmptySecret        = errors.New("secret must not be empty")
+	ErrInvalidLogLevel    = errors.New("log level must be debug, info, warn or error")
+	ErrInvalidDuration    = errors.New("duration must be positive")
+	ErrTLSIncomplete      = errors.New("TLS needs both a certificate and a key file")
+	ErrInvalidTLSVersion  = errors.New("TLS version must be 1.2 or 1.3")
+	ErrRedirectWithoutTLS = errors.New("HTTP redirect needs TLS and a port other than PORT")
+	ErrShutdownDelay      = errors.New("shutdown delay must not be negative or exceed the shutdown timeout")
+	ErrNegativeLimit      = errors.New("rate limit must not be negative")
+	ErrInvalidCount       = errors.New("value must be at least 1")
+	ErrInvalidTimezone    = errors.New("time zone must be an IANA name such as UTC or Europe/Moscow")
+	ErrInvalidAdminAddr   = errors.New("admin address must be host:port with a port other than PORT")
+	ErrDebugUnguarded     = errors.New("debug endpoints on the public port need an admin token")
+	ErrAdminUnguarded     = errors.New("an admin address other hosts can reach needs an admin token")
+	ErrInvalidExporter    = errors.New("tracing exporter must be none, stdout, otlp or otlp-file")
+	ErrTracingFile        = errors.New("the otlp-file exporter needs a tracing file")
+	ErrInvalidSampleRatio = errors.New("sample ratio must be between 0 and 1")
+	ErrInsecureDefault    = errors.New("built-in development default is not allowed in production")
+	ErrWeakSecret         = errors.New("secret is too short for production")
+	ErrReusedSecret       = errors.New("previous secret must differ from the current secret")
+	ErrInvalidProxy       = errors.New("trusted proxy must be an IP address or CIDR")
+)
+
+// minProductionSecretLen is the shortest JWT secret accepted in production
+const minProductionSecretLen = 32
+
+// FieldError describes a single configuration value that failed validation
+type FieldError struct {
+	Field string
+	Value string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("config: invalid %s %q: %v", e.Field, e.Value, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Validate checks every field and returns all problems joined together
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.Env {
+	case "development", "test", "staging", "production":
+	default:
+		errs = append(errs, &FieldError{Field: "ENV", Value: c.Env, Err: ErrInvalidEnv})
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, &FieldError{Field: "PORT", Value: c.Port, Err: ErrInvalidPort})
+	}
+
+	switch c.Storage {
+	case "postgres", "memory":
+	default:
+		errs = append(errs, &FieldError{Field: "STORAGE", Value: c.Storage, Err: ErrInvalidStorage})
+	}
+
+	if u, err := url.Parse(c.DatabaseURL); err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") || u.Host == "" {
+		errs = append(errs, &FieldError{Field: "DATABASE_URL", Value: redactURL(c.DatabaseURL), Err: ErrInvalidDatabaseURL})
+	}
+
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		errs = append(errs, &FieldError{Field: "TLS_KEY_FILE", Value: c.TLSKeyFile, Err: ErrTLSIncomplete})
+	}
+	if c.TLSMinVersion != "1.2" && c.TLSMinVersion != "1.3" {
+		errs = append(errs, &FieldError{Field: "TLS_MIN_VERSION", Value: c.TLSMinVersion, Err: ErrInvalidTLSVersion})
+	}
+	if c.HTTPRedirectPort != "" {
+		if port, err := strconv.Atoi(c.HTTPRedirectPort); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, &FieldError{Field: "HTTP_REDIRECT_PORT", Value: c.HTTPRedirectPort, Err: ErrInvalidPort})
+		} else if !c.TLSEnabled() || c.HTTPRedirectPort == c.Port {
+			errs = append(errs, &FieldError{Field: "HTTP_REDIRECT_PORT", Value: c.HTTPRedirectPort, Err: ErrRedirectWithoutTLS})
+		}
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errs = append(errs, &FieldError{Field: "SHUTDOWN_TIMEOUT", Value: c.ShutdownTimeout.String(), Err: ErrInvalidDuration})
+	}
+	if c.ShutdownDelay < 0 || c.ShutdownDelay >= c.ShutdownTimeout {
+		errs = append(errs, &FieldError{Field: "SHUTDOWN_DELAY", Value: c.ShutdownDelay.String(), Err: ErrShutdownDelay})
+	}
+
+	if c.JWTSecret == "" {
+		errs = append(errs, &FieldError{Field: "JWT_SECRET", Err: ErrEmptySecret})
+	}
+
+	if c.JWTAccessTTL <= 0 {
+		errs = append(errs, &FieldError{Field: "JWT_ACCESS_TTL", Value: c.JWTAccessTTL.String(), Err: ErrInvalidDuration})
+	}
+	if c.JWTRefreshTTL <= 0 {
+		errs = append(errs, &FieldError{Field: "JWT_REFRESH_TTL", Value: c.JWTRefreshTTL.String(), Err: ErrInvalidDuration})
+	}
+	if c.IdempotencyTTL <= 0 {
+		errs = append(errs, &FieldError{Field: "IDEMPOTENCY_TTL", Value: c.IdempotencyTTL.String(), Err: ErrInvalidDuration})
+	}
+
+	if c.JobWorkers < 1 {
+		errs = append(errs, &FieldError{Field: "JOB_WORKERS", Value: strconv.Itoa(c.JobWorkers), Err: ErrInvalidCount})
+	}
+	if c.JobPollInterval <= 0 {
+		errs = append(errs, &FieldError{Field: "JOB_POLL_INTERVAL", Value: c.JobPollInterval.String(), Err: ErrInvalidDuration})
+	}
+	if c.JobMaxAttempts < 1 {
+		errs = append(errs, &FieldError{Field: "JOB_MAX_ATTEMPTS", Value: strconv.Itoa(c.JobMaxAttempts), Err: ErrInvalidCount})
+	}
+	if c.JobRetention <= 0 {
+		errs = append(errs, &FieldError{Field: "JOB_RETENTION", Value: c.JobRetention.String(), Err: ErrInvalidDuration})
+	}
+	if _, err := c.SchedulerLocation(); err != nil {
+		errs = append(errs, &FieldError{Field: "SCHEDULER_TIMEZONE", Value: c.SchedulerTimezone, Err: ErrInvalidTimezone})
+	}
+
+	limits := []struct {
+		field string
+		value float64
+	}{
+		{"RATE_LIMIT_RPS", c.RateLimitRPS},
+		{"RATE_LIMIT_BURST", float64(c.RateLimitBurst)},
+		{"AUTH_RATE_LIMIT_RPS", c.AuthRateLimitRPS},
+		{"AUTH_RATE_LIMIT_BURST", float64(c.AuthRateLimitBurst)},
+	}
+	for _, l := range limits {
+		if l.value < 0 {
+			errs = append(errs, &FieldError{Field: l.field, Value: strconv.FormatFloat(l.value, 'g', -1, 64), Err: ErrNegativeLimit})
+		}
+	}
+
+	for _, proxy := range c.TrustedProxyList() {
+		if _, err := netip.ParsePrefix(proxy); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(proxy); err != nil {
+			errs = append(errs, &FieldError{Field: "TRUSTED_PROXIES", Value: proxy, Err: ErrInvalidProxy})
+		}
+	}
+
+	if _, err := c.SlogLevel(); err != nil {
+		errs = append(errs, &FieldError{Field: "LOG_LEVEL", Value: c.LogLevel, Err: ErrInvalidLogLevel})
+	}
+
+	if c.AdminAddr != "" {
+		host, port, err := net.SplitHostPort(c.AdminAddr)
+		if n, convErr := strconv.Atoi(port); err != nil || convErr != nil || n < 1 || n > 65535 || port == c.Port {
+			errs = append(errs, &FieldError{Field: "ADMIN_ADDR", Value: c.AdminAddr, Err: ErrInvalidAdminAddr})
+		} else if !isLoopback(host) && c.AdminToken == "" {
+			errs = append(errs, &FieldError{Field: "ADMIN_TOKEN", Err: ErrAdminUnguarded})
+		}
+	} else if c.DebugEndpoints && c.AdminToken == "" {
+		errs = append(errs, &FieldError{Field: "ADMIN_TOKEN", Err: ErrDebugUnguarded})
+	}
+
+	switch c.TracingExporter {
+	case "none", "stdout", "otlp":
+	case "otlp-file":
+		if c.TracingFile == "" {
+			errs = append(errs, &FieldError{Field: "TRACING_FILE", Err: ErrTracingFile})
+		}
+	default:
+		errs = append(errs, &FieldError{Field: "TRACING_EXPORTER", Value: c.TracingExporter, Err: ErrInvalidExporter})
+	}
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		errs = append(errs, &FieldError{Field: "TRACING_SAMPLE_RATIO", Value: strconv.FormatFloat(c.TracingSampleRatio, 'g', -1, 64), Err: ErrInvalidSampleRatio})
+	}
+
+	if c.IsProduction() {
+		errs = append(errs, c.validateProduction()...)
+	}
+
+	return errors.Join(errs...)
+}
+
+// IsProduction reports whether the server runs in the production environment
+func (c *Config) IsProduction() bool {
+	return c.Env == "production"
+}
+
+// TLSEnabled reports whether the server serves HTTPS
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+// CORSOriginList splits CORSOrigins into individual origins
+func (c *Config) CORSOriginList() []string {
+	return splitList(c.CORSOrigins)
+}
+
+// TrustedProxyList splits TrustedProxies into individual addresses and CIDRs
+func (c *Config) TrustedProxyList() []string {
+	return splitList(c.TrustedProxies)
+}
+
+// JWTPreviousSecretList splits JWTPreviousSecrets into individual secrets
+func (c *Config) JWTPreviousSecretList() []string {
+	return splitList(c.JWTPreviousSecrets)
+}
+
+// SlogLevel parses LogLevel into a log/slog level
+func (c *Config) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(c.LogLevel))
+	return level, err
+}
+
+// SchedulerLocation loads the SchedulerTimezone location
+func (c *Config) SchedulerLocation() (*time.Location, error) {
+	// time.LoadLocation treats "" as UTC and "Local" as the host zone;
+	// both would make schedules depend on where the server runs
+	if c.SchedulerTimezone == "" || c.SchedulerTimezone == "Local" {
+		return nil, ErrInvalidTimezone
+	}
+	return time.LoadLocation(c.SchedulerTimezone)
+}
+
+// validateProduction refuses development defaults, weak secrets and
+// in-memory storage
+func (c *Config) validateProduction() []error {
+	var errs []error
+
+	switch {
+	case c.JWTSecret == defaultJWTSecret:
+		errs = append(errs, &FieldError{Field: "JWT_SECRET", Value: redacted, Err: ErrInsecureDefault})
+	case c.JWTSecret != "" && len(c.JWTSecret) < minProductionSecretLen:
+		errs = append(errs, &FieldError{Field: "JWT_SECRET", Value: redacted, Err: ErrWeakSecret})
+	}
+
+	for _, secret := range c.JWTPreviousSecretList() {
+		switch {
+		case secret == c.JWTSecret:
+			errs = append(errs, &FieldError{Field: "JWT_PREVIOUS_SECRETS", Value: redacted, Err: ErrReusedSecret})
+		case secret == defaultJWTSecret:
+			errs = append(errs, &FieldError{Field: "JWT_PREVIOUS_SECRETS", Value: redacted, Err: ErrInsecureDefault})
+		case len(secret) < minProductionSecretLen:
+			errs = append(errs, &FieldError{Field: "JWT_PREVIOUS_SECRETS", Value: redacted, Err: ErrWeakSecret})
+		}
+	}
+
+	if c.AdminToken != "" && len(c.AdminToken) < minProductionSecretLen {
+		errs = append(errs, &FieldError{Field: "ADMIN_TOKEN", Value: redacted, Err: ErrWeakSecret})
+	}
+
+	if c.Storage == "memory" {
+		errs = append(errs, &FieldError{Field: "STORAGE", Value: c.Storage, Err: ErrVolatileStorage})
+	}
+
+	if u, err := url.Parse(c.DatabaseURL); err == nil && u.Host != "" {
+		password, _ := u.User.Password()
+		if c.DatabaseURL == defaultDatabaseURL || password == "" || password == defaultPassword() {
+			errs = append(errs, &FieldError{Field: "DATABASE_URL", Value: redactURL(c.DatabaseURL), Err: ErrInsecureDefault})
+		}
+	}
+
+	return errs
+}
+
+// defaultPassword returns the password embedded in the development database URL
+func defaultPassword() string {
+	u, _ := url.Parse(defaultDatabaseURL)
+	password, _ := u.User.Password()
+	return password
+}
+
+// isLoopback reports whether host only accepts connections from this
+// machine. An empty host listens on every interface.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	addr, err := netip.ParseAddr(host)
+	return err == nil && addr.IsLoopback()
+}
+
+// splitList splits a comma-separated value and drops empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateProductionPreviousSecrets(t *testing.T) {
+	current := strings.Repeat("k", minProductionSecretLen)
+	retired := strings.Repeat("r", minProductionSecretLen)
+
+	tests := []struct {
+		name     string
+		previous string
+		want     error
+	}{
+		{"strong previous secret", retired, nil},
+		{"development default", retired + "," + defaultJWTSecret, ErrInsecureDefault},
+		{"short previous secret", "tiny", ErrWeakSecret},
+		{"same as current secret", current, ErrReusedSecret},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("ENV", "production")
+			t.Setenv("DATABASE_URL", "postgres://app:s3cret-pass@db:5432/app")
+			t.Setenv("JWT_SECRET", current)
+			t.Setenv("JWT_PREVIOUS_SECRETS", tt.previous)
+
+			_, err := Load()
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Expected %v, got %v", tt.want, err)
+			}
+			var fe *FieldError
+			if !errors.As(err, &fe) || fe.Field != "JWT_PREVIOUS_SECRETS" {
+				t.Errorf("Expected FieldError for JWT_PREVIOUS_SECRETS, got %v", err)
+			}
+			if strings.Contains(err.Error(), tt.previous) {
+				t.Errorf("Expected secret to be redacted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateAdminAddr(t *testing.T) {
+	tests := []struct {
+		name  string
+		addr  string
+		token string
+		want  error
+	}{
+		{"localhost", "localhost:6060", "", nil},
+		{"IPv4 loopback", "127.0.0.1:6060", "", nil},
+		{"IPv6 loopback", "[::1]:6060", "", nil},
+		{"all interfaces", "0.0.0.0:6060", "", ErrAdminUnguarded},
+		{"host name", "admin.internal:6060", "", ErrAdminUnguarded},
+		{"all interfaces with token", ":6060", "admin-token", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Load("--admin-addr", tt.addr, "--admin-token="+tt.token)
+			if !errors.Is(err, tt.want) || (tt.want == nil && err != nil) {
+				t.Errorf("Expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+)
+
+// Change describes the outcome of a configuration reload
+type Change struct {
+	Old *Config
+	New *Config
+	// Changed lists the fields that were applied live
+	Changed []string
+	// RestartRequired lists the fields that changed on disk but only take effect after a restart
+	RestartRequired []string
+}
+
+// Watcher keeps the current configuration and re-reads it on demand
+type Watcher struct {
+	args []string
+
+	mu          sync.RWMutex
+	current     *Config
+	subscribers []func(Change)
+}
+
+// NewWatcher creates a watcher that starts from cfg and reloads using the same
+// command-line arguments that were passed to Load
+func NewWatcher(cfg *Config, args ...string) *Watcher {
+	return &Watcher{args: args, current: cfg}
+}
+
+// Current returns the configuration that is currently in effect
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Subscribe registers fn to be called after every reload that finds a difference
+func (w *Watcher) Subscribe(fn func(Change)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+}
+
+// Reload re-reads every configuration source. Live fields are applied and
+// announced to subscribers; restart-only fields keep their running values and
+// are reported in RestartRequired. The merged configuration is validated as a
+// whole, since live values may conflict with running ones. On error the
+// current configuration is kept.
+func (w *Watcher) Reload() (Change, error) {
+	loaded, err := Load(w.args...)
+	if err != nil {
+		return Change{}, err
+	}
+
+	w.mu.Lock()
+	old := w.current
+	next, change := merge(old, loaded)
+	if err := next.Validate(); err != nil {
+		w.mu.Unlock()
+		return Change{}, err
+	}
+	w.current = next
+	subscribers := append([]func(Change){}, w.subscribers...)
+	w.mu.Unlock()
+
+	if len(change.Changed) > 0 || len(change.RestartRequired) > 0 {
+		for _, fn := range subscribers {
+			fn(change)
+		}
+	}
+	return change, nil
+}
+
+// Watch reloads the configuration every time the process receives SIGHUP
+// until ctx is cancelled. Reload errors are passed to onError.
+func (w *Watcher) Watch(ctx context.Context, onError func(error)) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if _, err := w.Reload(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
+
+// merge applies the live fields of loaded on top of a copy of old
+func merge(old, loaded *Config) (*Config, Change) {
+	next := *old
+	change := Change{Old: old, New: &next}
+
+	ov := reflect.ValueOf(old).Elem()
+	lv := reflect.ValueOf(loaded).Elem()
+	nv := reflect.ValueOf(&next).Elem()
+	for i := 0; i < ov.NumField(); i++ {
+		if reflect.DeepEqual(ov.Field(i).Interface(), lv.Field(i).Interface()) {
+			continue
+		}
+		field := ov.Type().Field(i)
+		if field.Tag.Get("reload") == "restart" {
+			change.RestartRequired = append(change.RestartRequired, field.Name)
+			continue
+		}
+		nv.Field(i).Set(lv.Field(i))
+		change.Changed = append(change.Changed, field.Name)
+	}
+	return &next, change
+}
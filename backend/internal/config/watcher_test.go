@@ -0,0 +1,124 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfig(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcherReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "cors_origins: https://a.example\nlog_level: info\n")
+
+	cfg, err := Load("--config", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w := NewWatcher(cfg, "--config", path)
+
+	var got []Change
+	w.Subscribe(func(c Change) { got = append(got, c) })
+
+	writeConfig(t, path, "cors_origins: https://b.example\nlog_level: debug\nport: \"9999\"\n")
+	change, err := w.Reload()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if want := []string{"CORSOrigins", "LogLevel"}; !reflect.DeepEqual(change.Changed, want) {
+		t.Errorf("Expected changed %v, got %v", want, change.Changed)
+	}
+	if want := []string{"Port"}; !reflect.DeepEqual(change.RestartRequired, want) {
+		t.Errorf("Expected restart required %v, got %v", want, change.RestartRequired)
+	}
+	if len(got) != 1 {
+		t.Fatalf("Expected subscriber to be called once, got %d", len(got))
+	}
+
+	current := w.Current()
+	if current.CORSOrigins != "https://b.example" || current.LogLevel != "debug" {
+		t.Errorf("Expected live fields to be applied, got %v", current)
+	}
+	if current.Port != "8080" {
+		t.Errorf("Expected port to keep running value '8080', got '%s'", current.Port)
+	}
+}
+
+func TestWatcherReloadKeepsConfigOnError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "log_level: info\n")
+
+	cfg, err := Load("--config", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w := NewWatcher(cfg, "--config", path)
+
+	called := false
+	w.Subscribe(func(Change) { called = true })
+
+	writeConfig(t, path, "log_level: loud\n")
+	if _, err := w.Reload(); err == nil {
+		t.Fatal("Expected invalid config to fail reload")
+	}
+	if called {
+		t.Error("Expected subscribers not to be called on failed reload")
+	}
+	if w.Current() != cfg {
+		t.Error("Expected current config to be kept after failed reload")
+	}
+}
+
+func TestWatcherReloadValidatesMergedConfig(t *testing.T) {
+	t.Setenv("ENV", "production")
+	t.Setenv("DATABASE_URL", "postgres://app:s3cret-pass@db:5432/app")
+	current := strings.Repeat("a", minProductionSecretLen)
+	retired := strings.Repeat("b", minProductionSecretLen)
+	next := strings.Repeat("c", minProductionSecretLen)
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeConfig(t, path, "jwt_secret: "+current+"\njwt_previous_secrets: "+retired+"\n")
+	cfg, err := Load("--config", path)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w := NewWatcher(cfg, "--config", path)
+
+	// Valid on its own, but previous secrets only change on restart, so the
+	// running config would accept the new secret as a retired one
+	writeConfig(t, path, "jwt_secret: "+retired+"\njwt_previous_secrets: "+next+"\n")
+	if _, err := w.Reload(); !errors.Is(err, ErrReusedSecret) {
+		t.Fatalf("Expected ErrReusedSecret, got %v", err)
+	}
+	if w.Current() != cfg {
+		t.Error("Expected current config to be kept after failed reload")
+	}
+}
+
+func TestWatcherReloadWithoutChanges(t *testing.T) {
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	w := NewWatcher(cfg)
+
+	called := false
+	w.Subscribe(func(Change) { called = true })
+
+	if _, err := w.Reload(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if called {
+		t.Error("Expected subscribers not to be called when nothing changed")
+	}
+}
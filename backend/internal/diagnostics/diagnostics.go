@@ -0,0 +1,147 @@
+// Package diagnostics serves pprof profiles, goroutine dumps and runtime
+// statistics for debugging a running server
+package diagnostics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
+)
+
+// maxStackDump bounds the goroutine dump buffer
+const maxStackDump = 64 << 20
+
+// Register adds the debug endpoints under /debug to r:
+//
+//	/debug/pprof/...    net/http/pprof profiles
+//	/debug/goroutines   stack traces of every goroutine as text
+//	/debug/runtime      runtime and memory statistics as JSON
+//
+// When token is set every endpoint requires it as a bearer token. The
+// returned group lets callers add their own endpoints behind the same guard.
+func Register(r gin.IRouter, token string, started time.Time) *gin.RouterGroup {
+	group := r.Group("/debug")
+	if token != "" {
+		group.Use(RequireToken(token))
+	}
+
+	group.GET("/pprof/", gin.WrapF(pprof.Index))
+	group.GET("/pprof/cmdline", gin.WrapF(pprof.Cmdline))
+	group.GET("/pprof/profile", gin.WrapF(pprof.Profile))
+	group.GET("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.POST("/pprof/symbol", gin.WrapF(pprof.Symbol))
+	group.GET("/pprof/trace", gin.WrapF(pprof.Trace))
+	group.GET("/pprof/:profile", gin.WrapF(pprof.Index))
+	group.GET("/goroutines", Goroutines)
+	group.GET("/runtime", Runtime(started))
+	return group
+}
+
+// RequireToken rejects requests without "Authorization: Bearer <token>"
+func RequireToken(token string) gin.HandlerFunc {
+	want := []byte(token)
+	return func(c *gin.Context) {
+		scheme, got, _ := strings.Cut(c.GetHeader("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), want) != 1 {
+			c.Header("WWW-Authenticate", `Bearer realm="debug"`)
+			problem.Write(c, problem.New(http.StatusUnauthorized, "a valid admin token is required"))
+			return
+		}
+		c.Next()
+	}
+}
+
+// Goroutines writes the stack trace of every goroutine
+func Goroutines(c *gin.Context) {
+	buf := make([]byte, 1<<20)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) || len(buf) >= maxStackDump {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", buf)
+}
+
+// RuntimeStats is the body of GET /debug/runtime
+type RuntimeStats struct {
+	Version       string  `json:"version"`
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Goroutines    int     `json:"goroutines"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumCPU        int     `json:"num_cpu"`
+	CgoCalls      int64   `json:"cgo_calls"`
+	Memory        Memory  `json:"memory"`
+	GC            GC      `json:"gc"`
+}
+
+// Memory summarizes runtime.MemStats, in bytes
+type Memory struct {
+	HeapAlloc    uint64 `json:"heap_alloc"`
+	HeapInuse    uint64 `json:"heap_inuse"`
+	HeapIdle     uint64 `json:"heap_idle"`
+	HeapReleased uint64 `json:"heap_released"`
+	HeapObjects  uint64 `json:"heap_objects"`
+	StackInuse   uint64 `json:"stack_inuse"`
+	TotalAlloc   uint64 `json:"total_alloc"`
+	Sys          uint64 `json:"sys"`
+}
+
+// GC summarizes garbage collector activity
+type GC struct {
+	Cycles       uint32    `json:"cycles"`
+	PauseTotalMs float64   `json:"pause_total_ms"`
+	LastPauseMs  float64   `json:"last_pause_ms"`
+	Last         time.Time `json:"last,omitzero"`
+	NextHeap     uint64    `json:"next_heap"`
+	CPUFraction  float64   `json:"cpu_fraction"`
+}
+
+// Runtime serves runtime statistics of the process started at started
+func Runtime(started time.Time) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var m runtime.MemStats
+		runtime.ReadMemStats(&m)
+
+		stats := RuntimeStats{
+			Version:       version.Get().Version,
+			GoVersion:     runtime.Version(),
+			UptimeSeconds: time.Since(started).Seconds(),
+			Goroutines:    runtime.NumGoroutine(),
+			GOMAXPROCS:    runtime.GOMAXPROCS(0),
+			NumCPU:        runtime.NumCPU(),
+			CgoCalls:      runtime.NumCgoCall(),
+			Memory: Memory{
+				HeapAlloc:    m.HeapAlloc,
+				HeapInuse:    m.HeapInuse,
+				HeapIdle:     m.HeapIdle,
+				HeapReleased: m.HeapReleased,
+				HeapObjects:  m.HeapObjects,
+				StackInuse:   m.StackInuse,
+				TotalAlloc:   m.TotalAlloc,
+				Sys:          m.Sys,
+			},
+			GC: GC{
+				Cycles:       m.NumGC,
+				PauseTotalMs: float64(m.PauseTotalNs) / 1e6,
+				NextHeap:     m.NextGC,
+				CPUFraction:  m.GCCPUFraction,
+			},
+		}
+		if m.NumGC > 0 {
+			stats.GC.LastPauseMs = float64(m.PauseNs[(m.NumGC+255)%256]) / 1e6
+			stats.GC.Last = time.Unix(0, int64(m.LastGC)).UTC()
+		}
+		c.JSON(http.StatusOK, stats)
+	}
+}
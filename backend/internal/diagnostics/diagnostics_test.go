@@ -0,0 +1,80 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+)
+
+func newRouter(token string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	Register(router, token, time.Now().Add(-time.Minute))
+	return router
+}
+
+func get(router *gin.Engine, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestEndpoints(t *testing.T) {
+	router := newRouter("")
+
+	tests := []struct {
+		path     string
+		contains string
+	}{
+		{"/debug/pprof/", "goroutine"},
+		{"/debug/pprof/heap?debug=1", "heap profile"},
+		{"/debug/pprof/cmdline", ""},
+		{"/debug/goroutines", "goroutine "},
+	}
+	for _, tt := range tests {
+		w := get(router, tt.path, "")
+		if w.Code != http.StatusOK {
+			t.Errorf("%s: expected status 200, got %d", tt.path, w.Code)
+		}
+		if !strings.Contains(w.Body.String(), tt.contains) {
+			t.Errorf("%s: expected body to contain %q", tt.path, tt.contains)
+		}
+	}
+
+	w := get(router, "/debug/runtime", "")
+	var stats RuntimeStats
+	if err := json.Unmarshal(w.Body.Bytes(), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Goroutines == 0 || stats.Memory.Sys == 0 || stats.UptimeSeconds < 60 {
+		t.Errorf("Expected live runtime stats, got %+v", stats)
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	router := newRouter("admin-secret")
+
+	for _, token := range []string{"", "wrong"} {
+		w := get(router, "/debug/runtime", token)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("Token %q: expected status 401, got %d", token, w.Code)
+		}
+		if ct := w.Header().Get("Content-Type"); ct != problem.ContentType {
+			t.Errorf("Expected a problem response, got %q", ct)
+		}
+	}
+
+	if w := get(router, "/debug/pprof/", "admin-secret"); w.Code != http.StatusOK {
+		t.Errorf("Expected the admin token to be accepted, got %d", w.Code)
+	}
+}
@@ -0,0 +1,218 @@
+// Package events is an in-process bus for domain events. Publishers do not
+// know who listens: storage and handlers publish what happened and other
+// components subscribe to the events they care about.
+package events
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+)
+
+// Event is something that happened in the domain
+type Event interface {
+	// EventName identifies the event type in logs and metrics
+	EventName() string
+}
+
+// MessageCreated is published after a chat message is stored
+type MessageCreated struct {
+	Message models.Message
+}
+
+// TaskCompleted is published when a task is marked done
+type TaskCompleted struct {
+	Task models.Task
+}
+
+// UserRegistered is published after a user account is created
+type UserRegistered struct {
+	User models.User
+}
+
+func (MessageCreated) EventName() string { return "message.created" }
+func (TaskCompleted) EventName() string  { return "task.completed" }
+func (UserRegistered) EventName() string { return "user.registered" }
+
+// Delivery outcomes recorded in metrics
+const (
+	outcomeOK      = "ok"
+	outcomeError   = "error"
+	outcomePanic   = "panic"
+	outcomeDropped = "dropped"
+)
+
+// subscriber is a handler registered for one event type
+type subscriber struct {
+	name    string
+	async   bool
+	handler func(ctx context.Context, event Event) error
+}
+
+// Bus delivers published events to their subscribers. Synchronous
+// subscribers run in the publisher's goroutine before Publish returns;
+// asynchronous ones run in their own goroutine. Errors and panics are logged
+// and counted, never returned to the publisher.
+type Bus struct {
+	logger *slog.Logger
+
+	mu      sync.RWMutex
+	subs    map[string][]subscriber
+	closed  bool
+	pending sync.WaitGroup
+
+	published *prometheus.CounterVec
+	delivered *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	inFlight  prometheus.Gauge
+}
+
+// New creates a bus without subscribers
+func New(logger *slog.Logger) *Bus {
+	return &Bus{
+		logger: logger,
+		subs:   make(map[string][]subscriber),
+		published: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "events",
+			Name:      "published_total",
+			Help:      "Domain events published, by event.",
+		}, []string{"event"}),
+		delivered: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "events",
+			Name:      "deliveries_total",
+			Help:      "Event deliveries, by event, subscriber and outcome (ok, error, panic, dropped).",
+		}, []string{"event", "subscriber", "outcome"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "events",
+			Name:      "delivery_duration_seconds",
+			Help:      "Time subscribers took to handle an event, by event and subscriber.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"event", "subscriber"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "events",
+			Name:      "async_deliveries_in_flight",
+			Help:      "Asynchronous deliveries currently running.",
+		}),
+	}
+}
+
+// Subscribe registers fn to run synchronously for every event of type E.
+// The publisher waits for it, so it should be quick.
+func Subscribe[E Event](b *Bus, name string, fn func(ctx context.Context, event E) error) {
+	add(b, name, false, fn)
+}
+
+// SubscribeAsync registers fn to run in its own goroutine for every event
+// of type E. It gets the publisher's context values but not its
+// cancellation.
+func SubscribeAsync[E Event](b *Bus, name string, fn func(ctx context.Context, event E) error) {
+	add(b, name, true, fn)
+}
+
+func add[E Event](b *Bus, name string, async bool, fn func(ctx context.Context, event E) error) {
+	var zero E
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[zero.EventName()] = append(b.subs[zero.EventName()], subscriber{
+		name:  name,
+		async: async,
+		handler: func(ctx context.Context, event Event) error {
+			return fn(ctx, event.(E))
+		},
+	})
+}
+
+// Publish delivers event to its subscribers. After Close, asynchronous
+// subscribers no longer receive events.
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	name := event.EventName()
+	b.published.WithLabelValues(name).Inc()
+
+	b.mu.RLock()
+	subs := b.subs[name]
+	closed := b.closed
+	for _, s := range subs {
+		if s.async && !closed {
+			b.pending.Add(1)
+		}
+	}
+	b.mu.RUnlock()
+
+	for _, s := range subs {
+		switch {
+		case !s.async:
+			b.deliver(ctx, s, event)
+		case closed:
+			b.delivered.WithLabelValues(name, s.name, outcomeDropped).Inc()
+			b.logger.WarnContext(ctx, "event bus closed, dropping event", "event", name, "subscriber", s.name)
+		default:
+			b.inFlight.Inc()
+			go func() {
+				defer b.pending.Done()
+				defer b.inFlight.Dec()
+				b.deliver(context.WithoutCancel(ctx), s, event)
+			}()
+		}
+	}
+}
+
+// deliver runs one subscriber, recovering from panics
+func (b *Bus) deliver(ctx context.Context, s subscriber, event Event) {
+	name := event.EventName()
+	start := time.Now()
+	outcome := outcomeOK
+	defer func() {
+		if r := recover(); r != nil {
+			outcome = outcomePanic
+			b.logger.ErrorContext(ctx, "event subscriber panicked", "event", name, "subscriber", s.name, "panic", fmt.Sprint(r))
+		}
+		b.duration.WithLabelValues(name, s.name).Observe(time.Since(start).Seconds())
+		b.delivered.WithLabelValues(name, s.name, outcome).Inc()
+	}()
+
+	if err := s.handler(ctx, event); err != nil {
+		outcome = outcomeError
+		b.logger.ErrorContext(ctx, "event subscriber failed", "event", name, "subscriber", s.name, "error", err)
+	}
+}
+
+// Close stops asynchronous delivery of new events and waits for running
+// deliveries to finish or ctx to end
+func (b *Bus) Close(ctx context.Context) error {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		b.pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// RegisterMetrics exports publish and delivery counts, delivery latency and
+// the number of running asynchronous deliveries
+func (b *Bus) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{b.published, b.delivered, b.duration, b.inFlight} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
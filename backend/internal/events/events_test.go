@@ -0,0 +1,184 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+)
+
+func newTestBus() *Bus {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestSubscribersReceiveTheirEventType(t *testing.T) {
+	bus := newTestBus()
+	var users []UserRegistered
+	var messages int
+	Subscribe(bus, "users", func(_ context.Context, e UserRegistered) error {
+		users = append(users, e)
+		return nil
+	})
+	Subscribe(bus, "messages", func(context.Context, MessageCreated) error {
+		messages++
+		return nil
+	})
+
+	bus.Publish(context.Background(), UserRegistered{User: models.User{ID: 7, Email: "a@example.com"}})
+	bus.Publish(context.Background(), TaskCompleted{Task: models.Task{ID: 1}})
+
+	if len(users) != 1 || users[0].User.ID != 7 {
+		t.Errorf("users subscriber got %+v, want the registered user", users)
+	}
+	if messages != 0 {
+		t.Errorf("messages subscriber ran %d times, want 0", messages)
+	}
+}
+
+func TestSyncSubscribersRunInOrderBeforePublishReturns(t *testing.T) {
+	bus := newTestBus()
+	var order []string
+	for _, name := range []string{"first", "second", "third"} {
+		Subscribe(bus, name, func(context.Context, MessageCreated) error {
+			order = append(order, name)
+			return nil
+		})
+	}
+	bus.Publish(context.Background(), MessageCreated{})
+	if strings.Join(order, ",") != "first,second,third" {
+		t.Errorf("order = %v, want registration order", order)
+	}
+}
+
+func TestAsyncSubscribersOutliveTheRequest(t *testing.T) {
+	bus := newTestBus()
+	release := make(chan struct{})
+	got := make(chan error, 1)
+	SubscribeAsync(bus, "slow", func(ctx context.Context, _ TaskCompleted) error {
+		<-release
+		got <- ctx.Err()
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	bus.Publish(ctx, TaskCompleted{})
+	cancel()
+	close(release)
+
+	select {
+	case err := <-got:
+		if err != nil {
+			t.Errorf("async subscriber context error = %v, want none after the publisher's context ended", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("async subscriber did not run")
+	}
+}
+
+func TestPanicsAndErrorsAreContained(t *testing.T) {
+	bus := newTestBus()
+	reg := prometheus.NewRegistry()
+	if err := bus.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics() error = %v", err)
+	}
+	var after bool
+	Subscribe(bus, "panics", func(context.Context, UserRegistered) error { panic("boom") })
+	Subscribe(bus, "fails", func(context.Context, UserRegistered) error { return errors.New("smtp down") })
+	Subscribe(bus, "after", func(context.Context, UserRegistered) error {
+		after = true
+		return nil
+	})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	SubscribeAsync(bus, "async panics", func(context.Context, UserRegistered) error {
+		defer wg.Done()
+		panic("async boom")
+	})
+
+	bus.Publish(context.Background(), UserRegistered{})
+	wg.Wait()
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if !after {
+		t.Error("a panicking subscriber stopped later subscribers")
+	}
+	want := `
+# HELP backend_events_deliveries_total Event deliveries, by event, subscriber and outcome (ok, error, panic, dropped).
+# TYPE backend_events_deliveries_total counter
+backend_events_deliveries_total{event="user.registered",outcome="error",subscriber="fails"} 1
+backend_events_deliveries_total{event="user.registered",outcome="ok",subscriber="after"} 1
+backend_events_deliveries_total{event="user.registered",outcome="panic",subscriber="async panics"} 1
+backend_events_deliveries_total{event="user.registered",outcome="panic",subscriber="panics"} 1
+# HELP backend_events_published_total Domain events published, by event.
+# TYPE backend_events_published_total counter
+backend_events_published_total{event="user.registered"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"backend_events_deliveries_total", "backend_events_published_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloseWaitsForAsyncDeliveries(t *testing.T) {
+	bus := newTestBus()
+	var delivered int
+	var mu sync.Mutex
+	SubscribeAsync(bus, "slow", func(context.Context, MessageCreated) error {
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		delivered++
+		mu.Unlock()
+		return nil
+	})
+	var sync int
+	Subscribe(bus, "sync", func(context.Context, MessageCreated) error {
+		sync++
+		return nil
+	})
+
+	bus.Publish(context.Background(), MessageCreated{})
+	if err := bus.Close(context.Background()); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	mu.Lock()
+	if delivered != 1 {
+		t.Errorf("delivered = %d after Close, want 1", delivered)
+	}
+	mu.Unlock()
+
+	// After Close only synchronous subscribers still run
+	bus.Publish(context.Background(), MessageCreated{})
+	time.Sleep(30 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if delivered != 1 || sync != 2 {
+		t.Errorf("after Close delivered, sync = %d, %d; want 1, 2", delivered, sync)
+	}
+}
+
+func TestCloseDeadline(t *testing.T) {
+	bus := newTestBus()
+	release := make(chan struct{})
+	defer close(release)
+	SubscribeAsync(bus, "stuck", func(context.Context, MessageCreated) error {
+		<-release
+		return nil
+	})
+	bus.Publish(context.Background(), MessageCreated{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := bus.Close(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Close() error = %v, want DeadlineExceeded", err)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+)
+
+// LoginRequest is the body of POST /api/v1/auth/login
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// RefreshRequest is the body of POST /api/v1/auth/refresh and /logout
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// AuthHandler serves the authentication endpoints
+type AuthHandler struct {
+	service *auth.Service
+}
+
+// NewAuthHandler creates auth handlers backed by service
+func NewAuthHandler(service *auth.Service) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Login exchanges email and password for a token pair
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	tokens, err := h.service.Login(c.Request.Context(), req.Email, req.Password)
+	if err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Refresh exchanges a refresh token for a new token pair
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.RefreshToken == "" {
+		problem.Write(c, problem.Validation(problem.FieldError{Field: "refresh_token", Detail: "is required"}))
+		return
+	}
+
+	tokens, err := h.service.Refresh(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout revokes the caller's access token and optional refresh token.
+// It must run behind middleware.Auth.
+func (h *AuthHandler) Logout(c *gin.Context) {
+	claims, ok := middleware.Claims(c)
+	if !ok {
+		problem.Write(c, problem.New(http.StatusUnauthorized, "not authenticated"))
+		return
+	}
+
+	var req RefreshRequest
+	if c.Request.ContentLength > 0 && !bindJSON(c, &req) {
+		return
+	}
+
+	if err := h.service.Logout(claims, req.RefreshToken); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
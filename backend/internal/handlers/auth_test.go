@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+func newAuthRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	users := memory.NewUserRepository()
+	user, err := auth.NewUser("alice@example.com", "correct-horse", "user")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := users.Create(context.Background(), user); err != nil {
+		t.Fatal(err)
+	}
+	tokens := auth.NewTokenManager(auth.NewKeySet("test-secret"), auth.TokenOptions{
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	h := NewAuthHandler(auth.NewService(users, tokens))
+
+	router := gin.New()
+	router.POST("/auth/login", h.Login)
+	router.POST("/auth/refresh", h.Refresh)
+	router.POST("/auth/logout", middleware.Auth(tokens), h.Logout)
+	router.GET("/me", middleware.Auth(tokens), func(c *gin.Context) {
+		claims, _ := auth.FromContext(c.Request.Context())
+		c.JSON(http.StatusOK, gin.H{"email": claims.Email})
+	})
+	return router
+}
+
+func doJSON(router *gin.Engine, method, path, token string, body any) *httptest.ResponseRecorder {
+	var buf bytes.Buffer
+	if body != nil {
+		_ = json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestAuthFlow(t *testing.T) {
+	router := newAuthRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/auth/login", "", LoginRequest{Email: "alice@example.com", Password: "wrong"})
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected status 401 for bad password, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodPost, "/auth/login", "", LoginRequest{Email: "alice@example.com", Password: "correct-horse"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var pair auth.TokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &pair); err != nil {
+		t.Fatal(err)
+	}
+
+	if w = doJSON(router, http.MethodGet, "/me", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+	if w = doJSON(router, http.MethodGet, "/me", pair.AccessToken, nil); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 with token, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodPost, "/auth/refresh", "", RefreshRequest{RefreshToken: pair.RefreshToken})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on refresh, got %d", w.Code)
+	}
+	var next auth.TokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil {
+		t.Fatal(err)
+	}
+
+	w = doJSON(router, http.MethodPost, "/auth/logout", next.AccessToken, RefreshRequest{RefreshToken: next.RefreshToken})
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204 on logout, got %d", w.Code)
+	}
+	if w = doJSON(router, http.MethodGet, "/me", next.AccessToken, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 after logout, got %d", w.Code)
+	}
+	w = doJSON(router, http.MethodPost, "/auth/refresh", "", RefreshRequest{RefreshToken: next.RefreshToken})
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 refreshing after logout, got %d", w.Code)
+	}
+}
@@ -4,20 +4,34 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
 )
 
-// HealthCheck returns server health status
-func HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "sum25-go-flutter-course-backend",
-		"version": "1.0.0",
-	})
+// PingResponse is the body of GET /api/v1/ping
+type PingResponse struct {
+	Message string `json:"message"`
+}
+
+// HealthCheck returns server health status based on the readiness checks
+func HealthCheck(reg *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := reg.Ready(c.Request.Context())
+
+		status, code := "healthy", http.StatusOK
+		if !report.Healthy() {
+			status, code = "unhealthy", http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{
+			"status":  status,
+			"service": "sum25-go-flutter-course-backend",
+			"version": version.Get().Version,
+			"checks":  report.Checks,
+		})
+	}
 }
 
 // Ping returns a simple pong response
 func Ping(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"message": "pong",
-	})
+	c.JSON(http.StatusOK, PingResponse{Message: "pong"})
 }
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/health"
+)
+
+// Livez reports whether the process is alive
+func Livez(reg *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeReport(c, reg.Live(c.Request.Context()))
+	}
+}
+
+// Readyz reports whether the server can take traffic, with each check's result and timing
+func Readyz(reg *health.Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		writeReport(c, reg.Ready(c.Request.Context()))
+	}
+}
+
+func writeReport(c *gin.Context, report health.Report) {
+	status := http.StatusOK
+	if !report.Healthy() {
+		status = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, report)
+}
@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// adminRole may change any message
+const adminRole = "admin"
+
+// MessageHandler serves the chat message endpoints. Create, Update and
+// Delete must run behind middleware.Auth; only a message's author or an
+// admin may change it.
+type MessageHandler struct {
+	messages repository.MessageRepository
+}
+
+// NewMessageHandler creates message handlers backed by messages
+func NewMessageHandler(messages repository.MessageRepository) *MessageHandler {
+	return &MessageHandler{messages: messages}
+}
+
+// List returns a page of messages, oldest first
+func (h *MessageHandler) List(c *gin.Context) {
+	opts, ok := listOptions(c)
+	if !ok {
+		return
+	}
+	messages, err := h.messages.List(c.Request.Context(), opts)
+	if err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, messages)
+}
+
+// Create stores a new message
+func (h *MessageHandler) Create(c *gin.Context) {
+	var req models.CreateMessageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+
+	claims, ok := middleware.Claims(c)
+	if !ok || claims.UserID() == 0 {
+		problem.Write(c, problem.New(http.StatusUnauthorized, "not authenticated"))
+		return
+	}
+
+	msg := &models.Message{UserID: claims.UserID(), Username: claims.Email, Content: req.Content}
+	if err := h.messages.Create(c.Request.Context(), msg); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, msg)
+}
+
+// Get returns a single message
+func (h *MessageHandler) Get(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	msg, err := h.messages.GetByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, msg)
+}
+
+// Update replaces the content of a message
+func (h *MessageHandler) Update(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateMessageRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if !h.authorize(c, id) {
+		return
+	}
+
+	msg := &models.Message{ID: id, Content: req.Content}
+	if err := h.messages.Update(c.Request.Context(), msg); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, msg)
+}
+
+// Delete removes a message
+func (h *MessageHandler) Delete(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	if !h.authorize(c, id) {
+		return
+	}
+	if err := h.messages.Delete(c.Request.Context(), id); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// authorize reports whether the caller may change the message with the
+// given ID, writing the error response when not
+func (h *MessageHandler) authorize(c *gin.Context, id int64) bool {
+	claims, ok := middleware.Claims(c)
+	if !ok || claims.UserID() == 0 {
+		problem.Write(c, problem.New(http.StatusUnauthorized, "not authenticated"))
+		return false
+	}
+	msg, err := h.messages.GetByID(c.Request.Context(), id)
+	if err != nil {
+		problem.Write(c, err)
+		return false
+	}
+	if msg.UserID != claims.UserID() && claims.Role != adminRole {
+		problem.Write(c, problem.New(http.StatusForbidden, "only the author can change this message"))
+		return false
+	}
+	return true
+}
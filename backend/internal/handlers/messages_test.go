@@ -0,0 +1,152 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+// newMessageRouter returns a router serving messages and access tokens for
+// two users and an admin
+func newMessageRouter(t *testing.T) (router *gin.Engine, alice, bob, admin string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewTokenManager(auth.NewKeySet("test-secret"), auth.TokenOptions{
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	accessToken := func(id int64, email, role string) string {
+		pair, err := tokens.Issue(&models.User{ID: id, Email: email, Role: role})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair.AccessToken
+	}
+
+	h := NewMessageHandler(memory.NewMessageRepository())
+	router = gin.New()
+	router.GET("/messages", h.List)
+	router.POST("/messages", middleware.Auth(tokens), h.Create)
+	router.GET("/messages/:id", h.Get)
+	router.PUT("/messages/:id", middleware.Auth(tokens), h.Update)
+	router.DELETE("/messages/:id", middleware.Auth(tokens), h.Delete)
+	return router,
+		accessToken(1, "alice@example.com", "user"),
+		accessToken(2, "bob@example.com", "user"),
+		accessToken(3, "admin@example.com", "admin")
+}
+
+func TestMessageCRUD(t *testing.T) {
+	router, alice, _, _ := newMessageRouter(t)
+
+	if w := doJSON(router, http.MethodPost, "/messages", "", models.CreateMessageRequest{Content: "hello"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+	w := doJSON(router, http.MethodPost, "/messages", alice, models.CreateMessageRequest{Content: "hello"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.ID != 1 || created.UserID != 1 || created.Username != "alice@example.com" || created.Content != "hello" {
+		t.Errorf("Unexpected message %+v", created)
+	}
+
+	w = doJSON(router, http.MethodPut, "/messages/1", alice, models.UpdateMessageRequest{Content: "edited"})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on update, got %d", w.Code)
+	}
+
+	w = doJSON(router, http.MethodGet, "/messages", "", nil)
+	var list []models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Content != "edited" {
+		t.Errorf("Expected one edited message, got %+v", list)
+	}
+
+	if w = doJSON(router, http.MethodDelete, "/messages/1", alice, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 on delete, got %d", w.Code)
+	}
+	if w = doJSON(router, http.MethodGet, "/messages/1", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestMessageUsernameFromToken(t *testing.T) {
+	router, alice, _, _ := newMessageRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/messages", alice, map[string]string{"username": "bob", "content": "hello"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var created models.Message
+	if err := json.Unmarshal(w.Body.Bytes(), &created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Username != "alice@example.com" {
+		t.Errorf("Expected username from the token, got '%s'", created.Username)
+	}
+}
+
+func TestMessageOwnership(t *testing.T) {
+	router, alice, bob, admin := newMessageRouter(t)
+
+	w := doJSON(router, http.MethodPost, "/messages", alice, models.CreateMessageRequest{Content: "hello"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := doJSON(router, http.MethodPut, "/messages/1", "", models.UpdateMessageRequest{Content: "anonymous"}); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 for an anonymous edit, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPut, "/messages/1", bob, models.UpdateMessageRequest{Content: "bob was here"}); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user's edit, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodDelete, "/messages/1", bob, nil); w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for another user's delete, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPut, "/messages/1", admin, models.UpdateMessageRequest{Content: "moderated"}); w.Code != http.StatusOK {
+		t.Errorf("Expected status 200 for an admin edit, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodDelete, "/messages/1", admin, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 for an admin delete, got %d", w.Code)
+	}
+}
+
+func TestMessageValidation(t *testing.T) {
+	router, alice, _, _ := newMessageRouter(t)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   any
+		want   int
+	}{
+		{"missing content", http.MethodPost, "/messages", models.CreateMessageRequest{}, http.StatusBadRequest},
+		{"bad id", http.MethodGet, "/messages/abc", nil, http.StatusBadRequest},
+		{"unknown id", http.MethodPut, "/messages/42", models.UpdateMessageRequest{Content: "x"}, http.StatusNotFound},
+		{"bad limit", http.MethodGet, "/messages?limit=1000", nil, http.StatusBadRequest},
+		{"bad offset", http.MethodGet, "/messages?offset=-1", nil, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := doJSON(router, tt.method, tt.path, alice, tt.body); w.Code != tt.want {
+				t.Errorf("Expected status %d, got %d: %s", tt.want, w.Code, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// Paging limits for list endpoints
+const (
+	defaultPageSize = 50
+	maxPageSize     = 100
+)
+
+// pathID parses the :id path parameter, answering 400 when it is invalid
+func pathID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		problem.Write(c, problem.Validation(problem.FieldError{Field: "id", Detail: "must be a positive integer"}))
+		return 0, false
+	}
+	return id, true
+}
+
+// listOptions parses the limit and offset query parameters, answering 400
+// when they are invalid
+func listOptions(c *gin.Context) (repository.ListOptions, bool) {
+	opts := repository.ListOptions{Limit: defaultPageSize}
+	var fields []problem.FieldError
+	var err error
+	if v := c.Query("limit"); v != "" {
+		if opts.Limit, err = strconv.Atoi(v); err != nil || opts.Limit < 1 || opts.Limit > maxPageSize {
+			fields = append(fields, problem.FieldError{Field: "limit", Detail: "must be between 1 and " + strconv.Itoa(maxPageSize)})
+		}
+	}
+	if v := c.Query("offset"); v != "" {
+		if opts.Offset, err = strconv.Atoi(v); err != nil || opts.Offset < 0 {
+			fields = append(fields, problem.FieldError{Field: "offset", Detail: "must not be negative"})
+		}
+	}
+	if len(fields) > 0 {
+		problem.Write(c, problem.Validation(fields...))
+		return opts, false
+	}
+	return opts, true
+}
+
+// bindJSON decodes the request body into req and runs its Validate method
+// when it has one, answering 400 on failure
+func bindJSON(c *gin.Context, req any) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		problem.Write(c, problem.InvalidBody(err))
+		return false
+	}
+	if v, ok := req.(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			problem.Write(c, err)
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// TaskHandler serves the task endpoints. Every route must run behind
+// middleware.Auth; users only see their own tasks.
+type TaskHandler struct {
+	tasks repository.TaskRepository
+}
+
+// NewTaskHandler creates task handlers backed by tasks
+func NewTaskHandler(tasks repository.TaskRepository) *TaskHandler {
+	return &TaskHandler{tasks: tasks}
+}
+
+// List returns a page of the caller's tasks, optionally filtered by ?done=
+func (h *TaskHandler) List(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	opts, ok := listOptions(c)
+	if !ok {
+		return
+	}
+
+	filter := repository.TaskFilter{UserID: userID}
+	if v := c.Query("done"); v != "" {
+		done, err := strconv.ParseBool(v)
+		if err != nil {
+			problem.Write(c, problem.Validation(problem.FieldError{Field: "done", Detail: "must be true or false"}))
+			return
+		}
+		filter.Done = &done
+	}
+
+	tasks, err := h.tasks.List(c.Request.Context(), filter, opts)
+	if err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tasks)
+}
+
+// Create adds a task owned by the caller
+func (h *TaskHandler) Create(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	req, ok := bindTask(c)
+	if !ok {
+		return
+	}
+
+	task := &models.Task{UserID: userID, Title: req.Title, Description: req.Description, Done: req.Done}
+	if err := h.tasks.Create(c.Request.Context(), task); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, task)
+}
+
+// Get returns one of the caller's tasks
+func (h *TaskHandler) Get(c *gin.Context) {
+	task, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// Update replaces the title, description and done flag of a task
+func (h *TaskHandler) Update(c *gin.Context) {
+	task, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	req, ok := bindTask(c)
+	if !ok {
+		return
+	}
+
+	task.Title, task.Description, task.Done = req.Title, req.Description, req.Done
+	if err := h.tasks.Update(c.Request.Context(), task); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, task)
+}
+
+// Delete removes one of the caller's tasks
+func (h *TaskHandler) Delete(c *gin.Context) {
+	task, ok := h.owned(c)
+	if !ok {
+		return
+	}
+	if err := h.tasks.Delete(c.Request.Context(), task.ID); err != nil {
+		problem.Write(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// owned loads the task named by :id. Tasks of other users are reported as
+// missing so their IDs are not disclosed.
+func (h *TaskHandler) owned(c *gin.Context) (*models.Task, bool) {
+	userID, ok := callerID(c)
+	if !ok {
+		return nil, false
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return nil, false
+	}
+
+	task, err := h.tasks.GetByID(c.Request.Context(), id)
+	if err == nil && task.UserID != userID {
+		err = repository.ErrTaskNotFound
+	}
+	if err != nil {
+		problem.Write(c, err)
+		return nil, false
+	}
+	return task, true
+}
+
+// bindTask decodes and validates a task request body
+func bindTask(c *gin.Context) (*models.TaskRequest, bool) {
+	var req models.TaskRequest
+	if !bindJSON(c, &req) {
+		return nil, false
+	}
+	return &req, true
+}
+
+// callerID returns the authenticated user's ID
+func callerID(c *gin.Context) (int64, bool) {
+	claims, ok := middleware.Claims(c)
+	if !ok || claims.UserID() == 0 {
+		problem.Write(c, problem.New(http.StatusUnauthorized, "not authenticated"))
+		return 0, false
+	}
+	return claims.UserID(), true
+}
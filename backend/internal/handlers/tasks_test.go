@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/middleware"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+)
+
+// newTaskRouter returns a router serving tasks and access tokens for two
+// users
+func newTaskRouter(t *testing.T) (router *gin.Engine, alice, bob string) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	tokens := auth.NewTokenManager(auth.NewKeySet("test-secret"), auth.TokenOptions{
+		Issuer:     "test",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	accessToken := func(id int64) string {
+		pair, err := tokens.Issue(&models.User{ID: id, Email: "user@example.com"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return pair.AccessToken
+	}
+
+	h := NewTaskHandler(memory.NewTaskRepository())
+	router = gin.New()
+	group := router.Group("/tasks", middleware.Auth(tokens))
+	group.GET("", h.List)
+	group.POST("", h.Create)
+	group.GET("/:id", h.Get)
+	group.PUT("/:id", h.Update)
+	group.DELETE("/:id", h.Delete)
+	return router, accessToken(1), accessToken(2)
+}
+
+func TestTaskCRUD(t *testing.T) {
+	router, alice, _ := newTaskRouter(t)
+
+	if w := doJSON(router, http.MethodGet, "/tasks", "", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status 401 without token, got %d", w.Code)
+	}
+	if w := doJSON(router, http.MethodPost, "/tasks", alice, models.TaskRequest{Title: " "}); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected status 400 for empty title, got %d", w.Code)
+	}
+
+	w := doJSON(router, http.MethodPost, "/tasks", alice, models.TaskRequest{Title: "Write tests"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var task models.Task
+	if err := json.Unmarshal(w.Body.Bytes(), &task); err != nil {
+		t.Fatal(err)
+	}
+	if task.UserID != 1 || task.Done {
+		t.Errorf("Unexpected task %+v", task)
+	}
+
+	w = doJSON(router, http.MethodPut, "/tasks/1", alice, models.TaskRequest{Title: "Write tests", Done: true})
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200 on update, got %d", w.Code)
+	}
+
+	var list []models.Task
+	w = doJSON(router, http.MethodGet, "/tasks?done=true", alice, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || !list[0].Done {
+		t.Errorf("Expected one done task, got %+v", list)
+	}
+	w = doJSON(router, http.MethodGet, "/tasks?done=false", alice, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("Expected no open tasks, got %+v", list)
+	}
+
+	if w = doJSON(router, http.MethodDelete, "/tasks/1", alice, nil); w.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204 on delete, got %d", w.Code)
+	}
+	if w = doJSON(router, http.MethodGet, "/tasks/1", alice, nil); w.Code != http.StatusNotFound {
+		t.Errorf("Expected status 404 after delete, got %d", w.Code)
+	}
+}
+
+func TestTasksAreScopedToOwner(t *testing.T) {
+	router, alice, bob := newTaskRouter(t)
+
+	if w := doJSON(router, http.MethodPost, "/tasks", alice, models.TaskRequest{Title: "Private"}); w.Code != http.StatusCreated {
+		t.Fatalf("Expected status 201, got %d", w.Code)
+	}
+
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		if w := doJSON(router, method, "/tasks/1", bob, models.TaskRequest{Title: "Mine now"}); w.Code != http.StatusNotFound {
+			t.Errorf("%s: expected status 404 for another user's task, got %d", method, w.Code)
+		}
+	}
+
+	var list []models.Task
+	w := doJSON(router, http.MethodGet, "/tasks", bob, nil)
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Errorf("Expected bob to see no tasks, got %+v", list)
+	}
+}
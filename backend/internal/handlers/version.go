@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
+)
+
+// Version returns the build metadata of the running binary
+func Version(c *gin.Context) {
+	c.JSON(http.StatusOK, version.Get())
+}
@@ -0,0 +1,175 @@
+// Package health runs liveness and readiness checks
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check statuses
+const (
+	StatusPass = "pass"
+	StatusFail = "fail"
+)
+
+// ErrShuttingDown fails readiness while the server drains connections
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// CheckFunc reports a problem by returning an error
+type CheckFunc func(ctx context.Context) error
+
+// Pinger is implemented by dependencies such as database pools
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// PingCheck checks a dependency by pinging it
+func PingCheck(p Pinger) CheckFunc {
+	return p.Ping
+}
+
+// Result is the outcome of one check
+type Result struct {
+	Name     string        `json:"name"`
+	Status   string        `json:"status"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"-"`
+	Millis   float64       `json:"duration_ms"`
+}
+
+// Report is the outcome of all checks of one kind
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Healthy reports whether every check passed
+func (r Report) Healthy() bool {
+	return r.Status == StatusPass
+}
+
+type check struct {
+	name string
+	fn   CheckFunc
+}
+
+// Registry holds the liveness and readiness checks of the server
+type Registry struct {
+	timeout time.Duration
+
+	mu        sync.RWMutex
+	liveness  []check
+	readiness []check
+
+	shuttingDown atomic.Bool
+}
+
+// NewRegistry creates a registry that gives each check at most timeout to finish
+func NewRegistry(timeout time.Duration) *Registry {
+	return &Registry{timeout: timeout}
+}
+
+// AddLiveness registers a check that fails only when the process must be restarted
+func (r *Registry) AddLiveness(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.liveness = append(r.liveness, check{name, fn})
+}
+
+// AddReadiness registers a check that fails while the server cannot serve traffic
+func (r *Registry) AddReadiness(name string, fn CheckFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.readiness = append(r.readiness, check{name, fn})
+}
+
+// SetShuttingDown makes readiness fail so load balancers stop sending traffic
+func (r *Registry) SetShuttingDown() {
+	r.shuttingDown.Store(true)
+}
+
+// ShuttingDown reports whether SetShuttingDown was called
+func (r *Registry) ShuttingDown() bool {
+	return r.shuttingDown.Load()
+}
+
+// Live runs the liveness checks
+func (r *Registry) Live(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check{}, r.liveness...)
+	r.mu.RUnlock()
+	return r.run(ctx, checks)
+}
+
+// Ready runs the readiness checks, failing early while shutting down
+func (r *Registry) Ready(ctx context.Context) Report {
+	r.mu.RLock()
+	checks := append([]check{}, r.readiness...)
+	r.mu.RUnlock()
+
+	if r.ShuttingDown() {
+		checks = append([]check{{"shutdown", func(context.Context) error { return ErrShuttingDown }}}, checks...)
+	}
+	return r.run(ctx, checks)
+}
+
+// run executes checks concurrently and collects their results in order
+func (r *Registry) run(ctx context.Context, checks []check) Report {
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.timeout)
+		defer cancel()
+	}
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusPass, Checks: results}
+	for _, res := range results {
+		if res.Status != StatusPass {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// runCheck runs one check, treating a missed deadline or panic as failure
+func runCheck(ctx context.Context, c check) Result {
+	start := time.Now()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- errors.New("check panicked")
+			}
+		}()
+		done <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	res := Result{Name: c.name, Status: StatusPass, Duration: time.Since(start)}
+	res.Millis = float64(res.Duration.Microseconds()) / 1000
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
@@ -0,0 +1,67 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRegistryReady(t *testing.T) {
+	reg := NewRegistry(50 * time.Millisecond)
+	reg.AddReadiness("database", func(context.Context) error { return nil })
+	reg.AddReadiness("broker", func(context.Context) error { return errors.New("not running") })
+
+	report := reg.Ready(context.Background())
+	if report.Healthy() {
+		t.Fatal("Expected readiness to fail when one check fails")
+	}
+	if len(report.Checks) != 2 {
+		t.Fatalf("Expected 2 results, got %d", len(report.Checks))
+	}
+	if report.Checks[0].Name != "database" || report.Checks[0].Status != StatusPass {
+		t.Errorf("Expected database to pass, got %+v", report.Checks[0])
+	}
+	if report.Checks[1].Status != StatusFail || report.Checks[1].Error != "not running" {
+		t.Errorf("Expected broker to fail with its error, got %+v", report.Checks[1])
+	}
+}
+
+func TestRegistryTimeoutAndPanic(t *testing.T) {
+	reg := NewRegistry(20 * time.Millisecond)
+	reg.AddLiveness("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+	reg.AddLiveness("panics", func(context.Context) error { panic("boom") })
+
+	start := time.Now()
+	report := reg.Live(context.Background())
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected slow check to be cut off by the timeout")
+	}
+	for _, res := range report.Checks {
+		if res.Status != StatusFail {
+			t.Errorf("Expected %s to fail, got %+v", res.Name, res)
+		}
+	}
+}
+
+func TestRegistryShuttingDown(t *testing.T) {
+	reg := NewRegistry(time.Second)
+	reg.AddReadiness("database", func(context.Context) error { return nil })
+	reg.AddLiveness("process", func(context.Context) error { return nil })
+
+	if !reg.Ready(context.Background()).Healthy() {
+		t.Fatal("Expected readiness to pass before shutdown")
+	}
+
+	reg.SetShuttingDown()
+	report := reg.Ready(context.Background())
+	if report.Healthy() || report.Checks[0].Name != "shutdown" {
+		t.Errorf("Expected readiness to fail with shutdown check, got %+v", report)
+	}
+	if !reg.Live(context.Background()).Healthy() {
+		t.Error("Expected liveness to keep passing while shutting down")
+	}
+}
@@ -0,0 +1,149 @@
+// Package idempotency stores the responses of requests sent with an
+// Idempotency-Key so that retries can be answered without repeating them
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+)
+
+// Errors returned by stores
+var (
+	ErrInFlight    = errors.New("a request with this idempotency key is still being processed")
+	ErrKeyReused   = errors.New("idempotency key was already used with a different request")
+	ErrNotReserved = errors.New("idempotency key is not reserved")
+)
+
+// Response is a stored response replayed on retries
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Store keeps idempotency records. The in-memory store serves a single
+// instance; a shared store such as Redis or Postgres can implement the same
+// interface for clusters.
+type Store interface {
+	// Reserve claims key for a request with the given fingerprint for ttl.
+	// It returns nil, nil when the caller should process the request, the
+	// stored response when the key was already completed, ErrInFlight while
+	// the first request is still running and ErrKeyReused when the
+	// fingerprint differs from the first request's.
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error)
+	// Complete stores the response for a reserved key
+	Complete(ctx context.Context, key string, res Response) error
+	// Release forgets a reserved key so the request can be retried
+	Release(ctx context.Context, key string) error
+}
+
+// sweepInterval controls how often expired records are evicted
+const sweepInterval = time.Minute
+
+type record struct {
+	fingerprint string
+	response    *Response
+	expires     time.Time
+}
+
+// MemoryStore keeps idempotency records in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	records   map[string]*record
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(time.Now)
+}
+
+// NewMemoryStoreWithClock creates an in-memory store using now as its clock
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	return &MemoryStore{records: make(map[string]*record), now: now, lastSweep: now()}
+}
+
+// Reserve claims key unless an unexpired record already holds it
+func (s *MemoryStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	r, ok := s.records[key]
+	if !ok || !r.expires.After(now) {
+		s.records[key] = &record{fingerprint: fingerprint, expires: now.Add(ttl)}
+		return nil, nil
+	}
+
+	switch {
+	case r.fingerprint != fingerprint:
+		return nil, ErrKeyReused
+	case r.response == nil:
+		return nil, ErrInFlight
+	}
+	res := *r.response
+	res.Header = r.response.Header.Clone()
+	return &res, nil
+}
+
+// Complete stores the response for a reserved key
+func (s *MemoryStore) Complete(ctx context.Context, key string, res Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.records[key]
+	if !ok {
+		return ErrNotReserved
+	}
+	res.Header = res.Header.Clone()
+	r.response = &res
+	return nil
+}
+
+// Release forgets a reserved key
+func (s *MemoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// sweep drops expired records
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, r := range s.records {
+		if !r.expires.After(now) {
+			delete(s.records, key)
+		}
+	}
+}
+
+// RegisterMetrics exports the number of stored keys
+func (s *MemoryStore) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "idempotency",
+		Name:      "keys",
+		Help:      "Idempotency keys currently held by the in-memory store.",
+	}, func() float64 { return float64(s.Len()) }))
+}
+
+// Len returns the number of stored keys
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
@@ -0,0 +1,82 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestMemoryStoreLifecycle(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	store := NewMemoryStoreWithClock(clock.Now)
+	ctx := context.Background()
+
+	if res, err := store.Reserve(ctx, "k", "a", time.Hour); res != nil || err != nil {
+		t.Fatalf("Expected first reservation to succeed, got %v, %v", res, err)
+	}
+	if _, err := store.Reserve(ctx, "k", "a", time.Hour); !errors.Is(err, ErrInFlight) {
+		t.Fatalf("Expected ErrInFlight before completion, got %v", err)
+	}
+
+	header := http.Header{"Content-Type": {"application/json"}}
+	if err := store.Complete(ctx, "k", Response{Status: http.StatusCreated, Header: header, Body: []byte(`{"id":1}`)}); err != nil {
+		t.Fatal(err)
+	}
+	header.Set("Content-Type", "text/plain")
+
+	res, err := store.Reserve(ctx, "k", "a", time.Hour)
+	if err != nil || res == nil {
+		t.Fatalf("Expected the stored response, got %v, %v", res, err)
+	}
+	if res.Status != http.StatusCreated || string(res.Body) != `{"id":1}` || res.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Unexpected stored response %+v", res)
+	}
+
+	if _, err := store.Reserve(ctx, "k", "b", time.Hour); !errors.Is(err, ErrKeyReused) {
+		t.Errorf("Expected ErrKeyReused for a different fingerprint, got %v", err)
+	}
+
+	clock.Advance(time.Hour)
+	if res, err := store.Reserve(ctx, "k", "b", time.Hour); res != nil || err != nil {
+		t.Errorf("Expected an expired key to be reserved again, got %v, %v", res, err)
+	}
+}
+
+func TestMemoryStoreRelease(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	if _, err := store.Reserve(ctx, "k", "a", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Release(ctx, "k"); err != nil {
+		t.Fatal(err)
+	}
+	if res, err := store.Reserve(ctx, "k", "b", time.Hour); res != nil || err != nil {
+		t.Errorf("Expected a released key to be reserved again, got %v, %v", res, err)
+	}
+	if err := store.Complete(ctx, "missing", Response{}); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("Expected ErrNotReserved, got %v", err)
+	}
+}
+
+func TestMemoryStoreSweepsExpiredKeys(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	store := NewMemoryStoreWithClock(clock.Now)
+	ctx := context.Background()
+
+	store.Reserve(ctx, "old", "a", time.Second)
+	clock.Advance(sweepInterval)
+	store.Reserve(ctx, "new", "a", time.Hour)
+
+	if n := store.Len(); n != 1 {
+		t.Errorf("Expected 1 key after sweep, got %d", n)
+	}
+}
@@ -0,0 +1,101 @@
+// Package jobs runs background work outside the request path. Jobs are
+// stored by a Store, claimed by a Queue's worker pool, retried with
+// exponential backoff and dead-lettered once their attempts run out.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// State is the stage of a job's life
+type State string
+
+// Job states
+const (
+	// StatePending jobs wait for their RunAt time
+	StatePending State = "pending"
+	// StateRunning jobs are claimed by a worker until their lease ends
+	StateRunning State = "running"
+	// StateDone jobs finished successfully
+	StateDone State = "done"
+	// StateDead jobs failed permanently or ran out of attempts
+	StateDead State = "dead"
+)
+
+// ErrJobNotFound is returned for unknown job IDs
+var ErrJobNotFound = fmt.Errorf("job %w", repository.ErrNotFound)
+
+// ErrLeaseLost is returned when recording the outcome of an attempt that no
+// longer holds the job: its lease ended and another worker claimed it, or
+// the outcome was already recorded
+var ErrLeaseLost = errors.New("jobs: lease lost")
+
+// leaseExpired is the last error recorded for a job whose final attempt
+// outlived its lease
+const leaseExpired = "lease expired"
+
+// Job is a unit of background work
+type Job struct {
+	ID          int64           `json:"id"`
+	Kind        string          `json:"kind"`
+	Payload     json.RawMessage `json:"payload"`
+	State       State           `json:"state"`
+	Attempts    int             `json:"attempts"`
+	MaxAttempts int             `json:"max_attempts"`
+	RunAt       time.Time       `json:"run_at"`
+	LastError   string          `json:"last_error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// Store keeps jobs. The in-memory store serves a single instance and loses
+// its jobs on restart; the Postgres store lets several instances share one
+// queue.
+type Store interface {
+	// Enqueue stores a pending job, filling in its ID and timestamps
+	Enqueue(ctx context.Context, job *Job) error
+	// Claim marks up to limit jobs due at now as running for lease and
+	// counts an attempt for each. Running jobs whose lease has ended are
+	// claimed again, so work survives a crashed worker, unless they have
+	// used all their attempts; those move to the dead letters instead.
+	Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Job, error)
+	// Complete marks a job done. Complete, Retry and Kill only change a job
+	// that is running the given attempt and return ErrLeaseLost otherwise.
+	Complete(ctx context.Context, id int64, attempt int) error
+	// Retry makes a job pending again at runAt, recording its last error
+	Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastErr string) error
+	// Kill moves a job to the dead letters, recording its last error
+	Kill(ctx context.Context, id int64, attempt int, lastErr string) error
+	// Get returns the job with the given ID
+	Get(ctx context.Context, id int64) (*Job, error)
+	// List returns the jobs in state ordered by ID
+	List(ctx context.Context, state State, opts repository.ListOptions) ([]*Job, error)
+	// Purge deletes jobs that finished before the given time and returns how
+	// many were deleted. Dead letters are kept for inspection.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// permanentError marks failures that retrying cannot fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the job is dead-lettered without further attempts
+func Permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+// IsPermanent reports whether err was wrapped by Permanent
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
@@ -0,0 +1,343 @@
+// Package jobstest is the conformance suite every job store must pass.
+// Stores call Run from their own tests.
+package jobstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// Factory returns an empty store for a single test
+type Factory func(t *testing.T) jobs.Store
+
+// Run runs the whole suite against the store created by newStore
+func Run(t *testing.T, newStore Factory) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+
+	t.Run("EnqueueAndGet", func(t *testing.T) {
+		store := newStore(t)
+		job := mustEnqueue(t, store, "email", now)
+		if job.ID == 0 || job.CreatedAt.IsZero() || job.State != jobs.StatePending {
+			t.Fatalf("Enqueue() did not fill ID, CreatedAt and State: %+v", job)
+		}
+
+		got, err := store.Get(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if got.Kind != "email" || got.MaxAttempts != 3 || !got.RunAt.Equal(now) {
+			t.Errorf("Get() = %+v, want the enqueued job", got)
+		}
+		// Compare decoded payloads, as JSONB does not keep the original text
+		var payload struct{ To string }
+		if err := json.Unmarshal(got.Payload, &payload); err != nil || payload.To != "a@example.com" {
+			t.Errorf("Get() payload = %s, want the enqueued payload", got.Payload)
+		}
+
+		if _, err := store.Get(ctx, job.ID+100); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(unknown) error = %v, want ErrNotFound", err)
+		}
+	})
+
+	t.Run("ClaimDueOnly", func(t *testing.T) {
+		store := newStore(t)
+		later := mustEnqueue(t, store, "later", now.Add(time.Hour))
+		second := mustEnqueue(t, store, "second", now.Add(-time.Second))
+		first := mustEnqueue(t, store, "first", now.Add(-time.Minute))
+
+		claimed, err := store.Claim(ctx, now, 10, time.Minute)
+		if err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		if len(claimed) != 2 {
+			t.Fatalf("Claim() returned %d jobs, want 2", len(claimed))
+		}
+		ids := map[int64]bool{claimed[0].ID: true, claimed[1].ID: true}
+		if !ids[first.ID] || !ids[second.ID] {
+			t.Errorf("Claim() = %v, want jobs %d and %d", ids, first.ID, second.ID)
+		}
+		for _, j := range claimed {
+			if j.State != jobs.StateRunning || j.Attempts != 1 {
+				t.Errorf("claimed job %d state, attempts = %s, %d; want running, 1", j.ID, j.State, j.Attempts)
+			}
+		}
+
+		again, err := store.Claim(ctx, now, 10, time.Minute)
+		if err != nil || len(again) != 0 {
+			t.Errorf("second Claim() = %d jobs, %v; want none while leased", len(again), err)
+		}
+
+		due, err := store.Claim(ctx, now.Add(2*time.Hour), 10, time.Minute)
+		if err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		// The later job is now due and the two leases have expired
+		if len(due) != 3 {
+			t.Errorf("Claim() after leases expired returned %d jobs, want 3", len(due))
+		}
+		for _, j := range due {
+			if j.ID == later.ID && j.Attempts != 1 {
+				t.Errorf("later job attempts = %d, want 1", j.Attempts)
+			}
+			if j.ID == first.ID && j.Attempts != 2 {
+				t.Errorf("reclaimed job attempts = %d, want 2", j.Attempts)
+			}
+		}
+	})
+
+	t.Run("ClaimLimit", func(t *testing.T) {
+		store := newStore(t)
+		for range 5 {
+			mustEnqueue(t, store, "bulk", now)
+		}
+		claimed, err := store.Claim(ctx, now, 2, time.Minute)
+		if err != nil || len(claimed) != 2 {
+			t.Errorf("Claim(limit 2) = %d jobs, %v; want 2", len(claimed), err)
+		}
+	})
+
+	t.Run("ConcurrentClaims", func(t *testing.T) {
+		store := newStore(t)
+		const total = 20
+		for range total {
+			mustEnqueue(t, store, "race", now)
+		}
+
+		var (
+			mu   sync.Mutex
+			seen = make(map[int64]int)
+			wg   sync.WaitGroup
+		)
+		for range 4 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					claimed, err := store.Claim(ctx, now, 3, time.Minute)
+					if err != nil {
+						t.Errorf("Claim() error = %v", err)
+						return
+					}
+					if len(claimed) == 0 {
+						return
+					}
+					mu.Lock()
+					for _, j := range claimed {
+						seen[j.ID]++
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(seen) != total {
+			t.Errorf("claimed %d distinct jobs, want %d", len(seen), total)
+		}
+		for id, n := range seen {
+			if n != 1 {
+				t.Errorf("job %d claimed %d times", id, n)
+			}
+		}
+	})
+
+	t.Run("Outcomes", func(t *testing.T) {
+		store := newStore(t)
+		done := mustEnqueue(t, store, "done", now)
+		retried := mustEnqueue(t, store, "retried", now)
+		dead := mustEnqueue(t, store, "dead", now)
+		if _, err := store.Claim(ctx, now, 3, time.Minute); err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+
+		if err := store.Complete(ctx, done.ID, 1); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		retryAt := now.Add(time.Minute)
+		if err := store.Retry(ctx, retried.ID, 1, retryAt, "boom"); err != nil {
+			t.Fatalf("Retry() error = %v", err)
+		}
+		if err := store.Kill(ctx, dead.ID, 1, "gave up"); err != nil {
+			t.Fatalf("Kill() error = %v", err)
+		}
+
+		got := mustGet(t, store, done.ID)
+		if got.State != jobs.StateDone {
+			t.Errorf("completed job state = %s, want done", got.State)
+		}
+		got = mustGet(t, store, retried.ID)
+		if got.State != jobs.StatePending || !got.RunAt.Equal(retryAt) || got.LastError != "boom" || got.Attempts != 1 {
+			t.Errorf("retried job = %+v, want pending at %v with last error and 1 attempt", got, retryAt)
+		}
+		got = mustGet(t, store, dead.ID)
+		if got.State != jobs.StateDead || got.LastError != "gave up" {
+			t.Errorf("killed job = %+v, want dead with last error", got)
+		}
+
+		// Neither finished nor dead jobs are claimed again
+		claimed, err := store.Claim(ctx, now.Add(time.Hour), 10, time.Minute)
+		if err != nil || len(claimed) != 1 || claimed[0].ID != retried.ID {
+			t.Errorf("Claim() after outcomes = %v, %v; want only the retried job", claimed, err)
+		}
+
+		for name, err := range map[string]error{
+			"Complete": store.Complete(ctx, 9999, 1),
+			"Retry":    store.Retry(ctx, 9999, 1, now, "x"),
+			"Kill":     store.Kill(ctx, 9999, 1, "x"),
+		} {
+			if !errors.Is(err, repository.ErrNotFound) {
+				t.Errorf("%s(unknown) error = %v, want ErrNotFound", name, err)
+			}
+		}
+	})
+
+	t.Run("LostLease", func(t *testing.T) {
+		store := newStore(t)
+		job := mustEnqueue(t, store, "slow", now)
+		if _, err := store.Claim(ctx, now, 1, time.Minute); err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		// The lease ends and another worker claims the job for attempt 2
+		reclaimed, err := store.Claim(ctx, now.Add(2*time.Minute), 1, time.Minute)
+		if err != nil || len(reclaimed) != 1 || reclaimed[0].Attempts != 2 {
+			t.Fatalf("Claim() after the lease = %v, %v; want the job's second attempt", reclaimed, err)
+		}
+
+		for name, err := range map[string]error{
+			"Complete": store.Complete(ctx, job.ID, 1),
+			"Retry":    store.Retry(ctx, job.ID, 1, now, "late"),
+			"Kill":     store.Kill(ctx, job.ID, 1, "late"),
+		} {
+			if !errors.Is(err, jobs.ErrLeaseLost) {
+				t.Errorf("%s(first attempt) error = %v, want ErrLeaseLost", name, err)
+			}
+		}
+		if got := mustGet(t, store, job.ID); got.State != jobs.StateRunning || got.LastError != "" {
+			t.Errorf("job after stale outcomes = %+v, want it still running", got)
+		}
+
+		if err := store.Complete(ctx, job.ID, 2); err != nil {
+			t.Fatalf("Complete(second attempt) error = %v", err)
+		}
+		if err := store.Complete(ctx, job.ID, 2); !errors.Is(err, jobs.ErrLeaseLost) {
+			t.Errorf("Complete() twice error = %v, want ErrLeaseLost", err)
+		}
+	})
+
+	t.Run("ExpiredLeaseAtMaxAttempts", func(t *testing.T) {
+		store := newStore(t)
+		job := mustEnqueue(t, store, "hangs", now)
+		for attempt := 1; attempt <= job.MaxAttempts; attempt++ {
+			claimed, err := store.Claim(ctx, now.Add(time.Duration(attempt-1)*2*time.Minute), 1, time.Minute)
+			if err != nil || len(claimed) != 1 || claimed[0].Attempts != attempt {
+				t.Fatalf("Claim() for attempt %d = %v, %v", attempt, claimed, err)
+			}
+		}
+
+		// The last attempt's lease ends too; the job is not run again
+		later := now.Add(time.Duration(job.MaxAttempts) * 2 * time.Minute)
+		claimed, err := store.Claim(ctx, later, 1, time.Minute)
+		if err != nil || len(claimed) != 0 {
+			t.Fatalf("Claim() after the last lease = %v, %v; want nothing", claimed, err)
+		}
+		got := mustGet(t, store, job.ID)
+		if got.State != jobs.StateDead || got.LastError != "lease expired" || got.Attempts != job.MaxAttempts {
+			t.Errorf("job after its last lease = %+v, want it dead with \"lease expired\"", got)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		store := newStore(t)
+		var dead []int64
+		for range 3 {
+			j := mustEnqueue(t, store, "doomed", now)
+			dead = append(dead, j.ID)
+		}
+		mustEnqueue(t, store, "alive", now.Add(time.Hour))
+		if _, err := store.Claim(ctx, now, 10, time.Minute); err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		for _, id := range dead {
+			if err := store.Kill(ctx, id, 1, "gave up"); err != nil {
+				t.Fatalf("Kill() error = %v", err)
+			}
+		}
+
+		all, err := store.List(ctx, jobs.StateDead, repository.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		if len(all) != 3 || all[0].ID != dead[0] || all[2].ID != dead[2] {
+			t.Errorf("List(dead) = %d jobs, want the 3 killed in ID order", len(all))
+		}
+		page, err := store.List(ctx, jobs.StateDead, repository.ListOptions{Limit: 1, Offset: 1})
+		if err != nil || len(page) != 1 || page[0].ID != dead[1] {
+			t.Errorf("List(dead, limit 1, offset 1) = %v, %v; want job %d", page, err, dead[1])
+		}
+		pending, err := store.List(ctx, jobs.StatePending, repository.ListOptions{})
+		if err != nil || len(pending) != 1 {
+			t.Errorf("List(pending) = %d jobs, %v; want 1", len(pending), err)
+		}
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		store := newStore(t)
+		done := mustEnqueue(t, store, "done", now)
+		dead := mustEnqueue(t, store, "dead", now)
+		pending := mustEnqueue(t, store, "pending", now.Add(time.Hour))
+		if _, err := store.Claim(ctx, now, 10, time.Minute); err != nil {
+			t.Fatalf("Claim() error = %v", err)
+		}
+		if err := store.Complete(ctx, done.ID, 1); err != nil {
+			t.Fatalf("Complete() error = %v", err)
+		}
+		if err := store.Kill(ctx, dead.ID, 1, "gave up"); err != nil {
+			t.Fatalf("Kill() error = %v", err)
+		}
+
+		// Stores stamp UpdatedAt with their own clock, so purge relative to it
+		finished := mustGet(t, store, done.ID).UpdatedAt
+		if n, err := store.Purge(ctx, finished); err != nil || n != 0 {
+			t.Errorf("Purge(before it finished) = %d, %v; want 0", n, err)
+		}
+		if n, err := store.Purge(ctx, finished.Add(time.Second)); err != nil || n != 1 {
+			t.Errorf("Purge(after it finished) = %d, %v; want 1", n, err)
+		}
+		if _, err := store.Get(ctx, done.ID); !errors.Is(err, repository.ErrNotFound) {
+			t.Errorf("Get(purged) error = %v, want ErrNotFound", err)
+		}
+		mustGet(t, store, dead.ID)
+		mustGet(t, store, pending.ID)
+	})
+}
+
+func mustEnqueue(t *testing.T, store jobs.Store, kind string, runAt time.Time) *jobs.Job {
+	t.Helper()
+	job := &jobs.Job{
+		Kind:        kind,
+		Payload:     json.RawMessage(`{"to":"a@example.com"}`),
+		MaxAttempts: 3,
+		RunAt:       runAt,
+	}
+	if err := store.Enqueue(context.Background(), job); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	return job
+}
+
+func mustGet(t *testing.T, store jobs.Store, id int64) *jobs.Job {
+	t.Helper()
+	job, err := store.Get(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Get(%d) error = %v", id, err)
+	}
+	return job
+}
@@ -0,0 +1,184 @@
+package jobs
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// MemoryStore keeps jobs in process memory. Finished and dead jobs are kept
+// until the process exits.
+type MemoryStore struct {
+	mu     sync.Mutex
+	jobs   map[int64]*memoryJob
+	nextID int64
+}
+
+type memoryJob struct {
+	Job
+	lockedUntil time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: make(map[int64]*memoryJob), nextID: 1}
+}
+
+// Enqueue stores a pending job, filling in its ID and timestamps
+func (s *MemoryStore) Enqueue(ctx context.Context, job *Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	job.ID = s.nextID
+	job.State = StatePending
+	job.Attempts = 0
+	job.RunAt = job.RunAt.UTC().Truncate(time.Microsecond)
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	s.jobs[job.ID] = &memoryJob{Job: *job}
+	s.nextID++
+	return nil
+}
+
+// Claim marks up to limit due jobs as running, earliest RunAt first
+func (s *MemoryStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []*memoryJob
+	for _, j := range s.jobs {
+		switch {
+		case j.State == StatePending && !j.RunAt.After(now):
+		case j.State == StateRunning && !j.lockedUntil.After(now):
+			if j.Attempts >= j.MaxAttempts {
+				j.State = StateDead
+				j.LastError = leaseExpired
+				j.lockedUntil = time.Time{}
+				j.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+				continue
+			}
+		default:
+			continue
+		}
+		due = append(due, j)
+	}
+	slices.SortFunc(due, func(a, b *memoryJob) int {
+		if c := a.RunAt.Compare(b.RunAt); c != 0 {
+			return c
+		}
+		return int(a.ID - b.ID)
+	})
+
+	claimed := make([]*Job, 0, min(limit, len(due)))
+	for _, j := range due[:min(limit, len(due))] {
+		j.State = StateRunning
+		j.Attempts++
+		j.lockedUntil = now.Add(lease)
+		j.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		job := j.Job
+		claimed = append(claimed, &job)
+	}
+	return claimed, nil
+}
+
+// Complete marks a job done
+func (s *MemoryStore) Complete(ctx context.Context, id int64, attempt int) error {
+	return s.finish(id, attempt, func(j *memoryJob) {
+		j.State = StateDone
+	})
+}
+
+// Retry makes a job pending again at runAt
+func (s *MemoryStore) Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastErr string) error {
+	return s.finish(id, attempt, func(j *memoryJob) {
+		j.State = StatePending
+		j.RunAt = runAt.UTC().Truncate(time.Microsecond)
+		j.LastError = lastErr
+	})
+}
+
+// Kill moves a job to the dead letters
+func (s *MemoryStore) Kill(ctx context.Context, id int64, attempt int, lastErr string) error {
+	return s.finish(id, attempt, func(j *memoryJob) {
+		j.State = StateDead
+		j.LastError = lastErr
+	})
+}
+
+// finish applies an outcome to a job that is running attempt
+func (s *MemoryStore) finish(id int64, attempt int, fn func(j *memoryJob)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return ErrJobNotFound
+	}
+	if j.State != StateRunning || j.Attempts != attempt {
+		return ErrLeaseLost
+	}
+	fn(j)
+	j.lockedUntil = time.Time{}
+	j.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	return nil
+}
+
+// Get returns the job with the given ID
+func (s *MemoryStore) Get(ctx context.Context, id int64) (*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	j, ok := s.jobs[id]
+	if !ok {
+		return nil, ErrJobNotFound
+	}
+	job := j.Job
+	return &job, nil
+}
+
+// List returns the jobs in state ordered by ID
+func (s *MemoryStore) List(ctx context.Context, state State, opts repository.ListOptions) ([]*Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ids []int64
+	for id, j := range s.jobs {
+		if j.State == state {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	if opts.Offset >= len(ids) {
+		return []*Job{}, nil
+	}
+	ids = ids[max(opts.Offset, 0):]
+	if opts.Limit > 0 && opts.Limit < len(ids) {
+		ids = ids[:opts.Limit]
+	}
+
+	jobs := make([]*Job, 0, len(ids))
+	for _, id := range ids {
+		job := s.jobs[id].Job
+		jobs = append(jobs, &job)
+	}
+	return jobs, nil
+}
+
+// Purge deletes jobs that finished before the given time
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n int64
+	for id, j := range s.jobs {
+		if j.State == StateDone && j.UpdatedAt.Before(before) {
+			delete(s.jobs, id)
+			n++
+		}
+	}
+	return n, nil
+}
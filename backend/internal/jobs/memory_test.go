@@ -0,0 +1,14 @@
+package jobs_test
+
+import (
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs/jobstest"
+)
+
+func TestMemoryStoreConformance(t *testing.T) {
+	jobstest.Run(t, func(t *testing.T) jobs.Store {
+		return jobs.NewMemoryStore()
+	})
+}
@@ -0,0 +1,158 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+const jobColumns = "id, kind, payload, state, attempts, max_attempts, run_at, last_error, created_at, updated_at"
+
+// PostgresStore keeps jobs in the jobs table. Claims lock rows with
+// FOR UPDATE SKIP LOCKED, so any number of instances can share the queue
+// without handing the same job to two workers.
+type PostgresStore struct {
+	pool *pgxpool.Pool
+}
+
+// NewPostgresStore creates a store using pool
+func NewPostgresStore(pool *pgxpool.Pool) *PostgresStore {
+	return &PostgresStore{pool: pool}
+}
+
+// Enqueue inserts a pending job, filling in its ID and timestamps
+func (s *PostgresStore) Enqueue(ctx context.Context, job *Job) error {
+	payload := job.Payload
+	if len(payload) == 0 {
+		payload = json.RawMessage("null")
+	}
+	job.State = StatePending
+	job.Attempts = 0
+	return s.pool.QueryRow(ctx,
+		`INSERT INTO jobs (kind, payload, max_attempts, run_at) VALUES ($1, $2, $3, $4)
+		 RETURNING id, run_at, created_at, updated_at`,
+		job.Kind, payload, job.MaxAttempts, job.RunAt,
+	).Scan(&job.ID, &job.RunAt, &job.CreatedAt, &job.UpdatedAt)
+}
+
+// Claim marks up to limit due jobs as running, earliest RunAt first. Rows
+// locked by a concurrent claim are skipped rather than waited for.
+func (s *PostgresStore) Claim(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*Job, error) {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE jobs SET state = 'dead', last_error = $2, locked_until = NULL, updated_at = now()
+		 WHERE state = 'running' AND locked_until <= $1 AND attempts >= max_attempts`,
+		now, leaseExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.pool.Query(ctx,
+		`UPDATE jobs SET state = 'running', attempts = attempts + 1, locked_until = $3, updated_at = now()
+		 WHERE id IN (
+		     SELECT id FROM jobs
+		     WHERE (state = 'pending' AND run_at <= $1) OR (state = 'running' AND locked_until <= $1 AND attempts < max_attempts)
+		     ORDER BY run_at, id
+		     LIMIT $2
+		     FOR UPDATE SKIP LOCKED
+		 )
+		 RETURNING `+jobColumns,
+		now, limit, now.Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanJob)
+}
+
+// Complete marks a job done
+func (s *PostgresStore) Complete(ctx context.Context, id int64, attempt int) error {
+	return s.finish(ctx, id,
+		`UPDATE jobs SET state = 'done', locked_until = NULL, updated_at = now()
+		 WHERE id = $1 AND state = 'running' AND attempts = $2`,
+		id, attempt)
+}
+
+// Retry makes a job pending again at runAt
+func (s *PostgresStore) Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastErr string) error {
+	return s.finish(ctx, id,
+		`UPDATE jobs SET state = 'pending', run_at = $3, last_error = $4, locked_until = NULL, updated_at = now()
+		 WHERE id = $1 AND state = 'running' AND attempts = $2`,
+		id, attempt, runAt, lastErr)
+}
+
+// Kill moves a job to the dead letters
+func (s *PostgresStore) Kill(ctx context.Context, id int64, attempt int, lastErr string) error {
+	return s.finish(ctx, id,
+		`UPDATE jobs SET state = 'dead', last_error = $3, locked_until = NULL, updated_at = now()
+		 WHERE id = $1 AND state = 'running' AND attempts = $2`,
+		id, attempt, lastErr)
+}
+
+// finish runs an outcome update fenced by state and attempt. When no row
+// matches, it tells an unknown job from one whose lease was lost.
+func (s *PostgresStore) finish(ctx context.Context, id int64, sql string, args ...any) error {
+	tag, err := s.pool.Exec(ctx, sql, args...)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() > 0 {
+		return nil
+	}
+	var exists bool
+	if err := s.pool.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1)", id).Scan(&exists); err != nil {
+		return err
+	}
+	if !exists {
+		return ErrJobNotFound
+	}
+	return ErrLeaseLost
+}
+
+// Get returns the job with the given ID
+func (s *PostgresStore) Get(ctx context.Context, id int64) (*Job, error) {
+	rows, err := s.pool.Query(ctx, "SELECT "+jobColumns+" FROM jobs WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	job, err := pgx.CollectExactlyOneRow(rows, scanJob)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrJobNotFound
+	}
+	return job, err
+}
+
+// List returns the jobs in state ordered by ID
+func (s *PostgresStore) List(ctx context.Context, state State, opts repository.ListOptions) ([]*Job, error) {
+	var limit *int
+	if opts.Limit > 0 {
+		limit = &opts.Limit
+	}
+	rows, err := s.pool.Query(ctx,
+		"SELECT "+jobColumns+" FROM jobs WHERE state = $1 ORDER BY id LIMIT $2 OFFSET $3",
+		state, limit, max(opts.Offset, 0))
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanJob)
+}
+
+// Purge deletes jobs that finished before the given time
+func (s *PostgresStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := s.pool.Exec(ctx, "DELETE FROM jobs WHERE state = 'done' AND updated_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func scanJob(row pgx.CollectableRow) (*Job, error) {
+	var j Job
+	err := row.Scan(&j.ID, &j.Kind, &j.Payload, &j.State, &j.Attempts, &j.MaxAttempts,
+		&j.RunAt, &j.LastError, &j.CreatedAt, &j.UpdatedAt)
+	return &j, err
+}
@@ -0,0 +1,49 @@
+package jobs_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs/jobstest"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/postgres"
+)
+
+// TestPostgresStoreConformance runs the shared suite against the database
+// named by TEST_DATABASE_URL. The jobs table is emptied before every test.
+func TestPostgresStoreConformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	migrations, err := migrate.Load(os.DirFS("../../migrations"))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	driver, err := migrate.NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer driver.Close(ctx)
+	if err := migrate.New(driver, migrations, io.Discard).Up(ctx, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := postgres.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	jobstest.Run(t, func(t *testing.T) jobs.Store {
+		if _, err := pool.Exec(ctx, "TRUNCATE jobs RESTART IDENTITY"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return jobs.NewPostgresStore(pool)
+	})
+}
@@ -0,0 +1,350 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+)
+
+// ErrAlreadyStarted is returned when Start is called twice
+var ErrAlreadyStarted = errors.New("jobs: queue already started")
+
+// Options configures a Queue. Zero values take the defaults noted.
+type Options struct {
+	// Workers is the number of jobs run at once, 4 by default
+	Workers int
+	// PollInterval is how often the store is checked for due jobs when no
+	// job was enqueued in process, 1s by default
+	PollInterval time.Duration
+	// Lease bounds how long one attempt may run before another worker may
+	// claim the job again, 5m by default
+	Lease time.Duration
+	// MaxAttempts is the number of attempts before a job is dead-lettered,
+	// 5 by default
+	MaxAttempts int
+	// MinBackoff is the delay before the first retry, doubled for every
+	// further attempt up to MaxBackoff; 1s and 1h by default
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 5 * time.Minute
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.MinBackoff <= 0 {
+		o.MinBackoff = time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	return o
+}
+
+// Type names a kind of job together with the payload type its handler
+// receives, so enqueueing and handling cannot disagree
+type Type[T any] string
+
+// handlerFunc runs one attempt of a job
+type handlerFunc func(ctx context.Context, job *Job) error
+
+// Queue runs jobs from a Store on a pool of workers
+type Queue struct {
+	store  Store
+	opts   Options
+	logger *slog.Logger
+	now    func() time.Time
+
+	mu       sync.RWMutex
+	handlers map[string]handlerFunc
+
+	wake    chan struct{}
+	idle    chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	running sync.WaitGroup
+	cancel  context.CancelFunc
+	started bool
+	halted  bool
+
+	// recording is held while an outcome is written to the store, so Stop
+	// can shut out jobs that outlive its deadline
+	recording sync.RWMutex
+	abandoned bool
+
+	processed *prometheus.CounterVec
+	inFlight  prometheus.Gauge
+}
+
+// New creates a queue over store. Register handlers before Start.
+func New(store Store, opts Options, logger *slog.Logger) *Queue {
+	return &Queue{
+		store:    store,
+		opts:     opts.withDefaults(),
+		logger:   logger,
+		now:      time.Now,
+		handlers: make(map[string]handlerFunc),
+		wake:     make(chan struct{}, 1),
+		idle:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		processed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "jobs",
+			Name:      "processed_total",
+			Help:      "Job attempts finished, by kind and outcome (succeeded, retried, dead).",
+		}, []string{"kind", "outcome"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: "jobs",
+			Name:      "running",
+			Help:      "Jobs currently being run by this instance.",
+		}),
+	}
+}
+
+// Handle registers fn for jobs of type typ. Payloads that do not decode
+// into T are dead-lettered.
+func Handle[T any](q *Queue, typ Type[T], fn func(ctx context.Context, payload T) error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[string(typ)] = func(ctx context.Context, job *Job) error {
+		var payload T
+		if err := json.Unmarshal(job.Payload, &payload); err != nil {
+			return Permanent(fmt.Errorf("decode payload: %w", err))
+		}
+		return fn(ctx, payload)
+	}
+}
+
+// EnqueueOption adjusts a job before it is stored
+type EnqueueOption func(*Job)
+
+// RunAt delays a job until t
+func RunAt(t time.Time) EnqueueOption {
+	return func(j *Job) { j.RunAt = t }
+}
+
+// MaxAttempts overrides the queue's attempt limit for one job
+func MaxAttempts(n int) EnqueueOption {
+	return func(j *Job) { j.MaxAttempts = n }
+}
+
+// Enqueue stores a job of type typ carrying payload
+func Enqueue[T any](ctx context.Context, q *Queue, typ Type[T], payload T, opts ...EnqueueOption) (*Job, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("jobs: encode %s payload: %w", typ, err)
+	}
+	job := &Job{Kind: string(typ), Payload: data, MaxAttempts: q.opts.MaxAttempts, RunAt: q.now()}
+	for _, opt := range opts {
+		opt(job)
+	}
+	if err := q.store.Enqueue(ctx, job); err != nil {
+		return nil, err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return job, nil
+}
+
+// Start launches the dispatcher. Jobs run until Stop is called.
+func (q *Queue) Start(context.Context) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.started {
+		return ErrAlreadyStarted
+	}
+	q.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	q.cancel = cancel
+	go q.dispatch(ctx)
+	return nil
+}
+
+// Stop stops claiming jobs and waits for running ones to finish. When ctx
+// ends first, running jobs are cancelled and Stop returns once outcomes
+// being written have been stored; jobs finishing later leave the store
+// alone and are claimed again when their lease ends. Stopping a queue that
+// is not running does nothing.
+func (q *Queue) Stop(ctx context.Context) error {
+	q.mu.Lock()
+	if !q.started || q.halted {
+		q.mu.Unlock()
+		return nil
+	}
+	q.halted = true
+	q.mu.Unlock()
+
+	close(q.stop)
+	<-q.stopped
+
+	done := make(chan struct{})
+	go func() {
+		q.running.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		q.cancel()
+		return nil
+	case <-ctx.Done():
+		q.cancel()
+		q.recording.Lock()
+		q.abandoned = true
+		q.recording.Unlock()
+		return ctx.Err()
+	}
+}
+
+// dispatch claims due jobs whenever a worker is free
+func (q *Queue) dispatch(ctx context.Context) {
+	defer close(q.stopped)
+	slots := make(chan struct{}, q.opts.Workers)
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		claimed := 0
+		if free := q.opts.Workers - len(slots); free > 0 {
+			jobs, err := q.store.Claim(ctx, q.now(), free, q.opts.Lease)
+			if err != nil {
+				q.logger.Error("claim jobs failed", "error", err)
+			}
+			claimed = len(jobs)
+			for _, job := range jobs {
+				slots <- struct{}{}
+				q.running.Add(1)
+				go func() {
+					defer func() {
+						<-slots
+						q.running.Done()
+						select {
+						case q.idle <- struct{}{}:
+						default:
+						}
+					}()
+					q.run(ctx, job)
+				}()
+			}
+			// A full batch suggests more jobs are due
+			if claimed > 0 && claimed == free {
+				continue
+			}
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(q.opts.PollInterval)
+		select {
+		case <-q.stop:
+			return
+		case <-q.wake:
+		case <-q.idle:
+		case <-timer.C:
+		}
+	}
+}
+
+// run makes one attempt at job and records the outcome
+func (q *Queue) run(ctx context.Context, job *Job) {
+	q.inFlight.Inc()
+	defer q.inFlight.Dec()
+
+	logger := q.logger.With("job_id", job.ID, "kind", job.Kind, "attempt", job.Attempts)
+	ctx, cancel := context.WithTimeout(ctx, q.opts.Lease)
+	err := q.attempt(ctx, job)
+	cancel()
+
+	q.recording.RLock()
+	defer q.recording.RUnlock()
+	if q.abandoned {
+		logger.Warn("job finished after the queue stopped, leaving it for its lease to expire", "error", err)
+		return
+	}
+
+	// Record the outcome even when the queue is being stopped
+	storeCtx := context.WithoutCancel(ctx)
+	var outcome string
+	switch {
+	case err == nil:
+		outcome = "succeeded"
+		err = q.store.Complete(storeCtx, job.ID, job.Attempts)
+	case IsPermanent(err) || job.Attempts >= job.MaxAttempts:
+		outcome = "dead"
+		logger.Error("job dead-lettered", "error", err)
+		err = q.store.Kill(storeCtx, job.ID, job.Attempts, err.Error())
+	default:
+		outcome = "retried"
+		delay := q.backoff(job.Attempts)
+		logger.Warn("job failed, retrying", "error", err, "retry_in", delay.String())
+		err = q.store.Retry(storeCtx, job.ID, job.Attempts, q.now().Add(delay), err.Error())
+	}
+	if errors.Is(err, ErrLeaseLost) {
+		logger.Warn("job lease lost, outcome discarded", "outcome", outcome)
+		return
+	}
+	q.processed.WithLabelValues(job.Kind, outcome).Inc()
+	if err != nil {
+		logger.Error("record job outcome failed", "outcome", outcome, "error", err)
+	}
+}
+
+// attempt calls the job's handler, turning panics into errors
+func (q *Queue) attempt(ctx context.Context, job *Job) (err error) {
+	q.mu.RLock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		return Permanent(fmt.Errorf("no handler for job kind %q", job.Kind))
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// backoff returns the delay before the retry following attempt
+func (q *Queue) backoff(attempt int) time.Duration {
+	delay := q.opts.MinBackoff
+	for i := 1; i < attempt && delay < q.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, q.opts.MaxBackoff)
+}
+
+// RegisterMetrics exports job outcomes and the number of running jobs
+func (q *Queue) RegisterMetrics(reg prometheus.Registerer) error {
+	for _, c := range []prometheus.Collector{q.processed, q.inFlight} {
+		if err := reg.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
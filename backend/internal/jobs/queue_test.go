@@ -0,0 +1,361 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/jobs"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+type email struct {
+	To string `json:"to"`
+}
+
+const sendEmail jobs.Type[email] = "send_email"
+
+func newQueue(t *testing.T, store jobs.Store, opts jobs.Options) *jobs.Queue {
+	t.Helper()
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 5 * time.Millisecond
+	}
+	if opts.MinBackoff == 0 {
+		opts.MinBackoff = time.Millisecond
+	}
+	q := jobs.New(store, opts, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := q.Stop(ctx); err != nil {
+			t.Errorf("Stop() error = %v", err)
+		}
+	})
+	return q
+}
+
+// waitFor polls the store until job id reaches state
+func waitFor(t *testing.T, store jobs.Store, id int64, state jobs.State) *jobs.Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := store.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.State == state {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job %d is %s after 5s, want %s", id, job.State, state)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestQueueRunsTypedJob(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{})
+	got := make(chan string, 1)
+	jobs.Handle(q, sendEmail, func(ctx context.Context, e email) error {
+		got <- e.To
+		return nil
+	})
+	if err := q.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	job, err := jobs.Enqueue(context.Background(), q, sendEmail, email{To: "a@example.com"})
+	if err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if to := <-got; to != "a@example.com" {
+		t.Errorf("handler got %q, want a@example.com", to)
+	}
+	if done := waitFor(t, store, job.ID, jobs.StateDone); done.Attempts != 1 {
+		t.Errorf("attempts = %d, want 1", done.Attempts)
+	}
+}
+
+func TestQueueRetriesThenSucceeds(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{})
+	var calls atomic.Int32
+	jobs.Handle(q, sendEmail, func(ctx context.Context, e email) error {
+		if calls.Add(1) < 3 {
+			return errors.New("smtp unavailable")
+		}
+		return nil
+	})
+	q.Start(context.Background())
+
+	job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{To: "a@example.com"})
+	done := waitFor(t, store, job.ID, jobs.StateDone)
+	if done.Attempts != 3 || done.LastError != "smtp unavailable" {
+		t.Errorf("job = %+v, want done after 3 attempts keeping the last error", done)
+	}
+}
+
+func TestQueueDeadLetters(t *testing.T) {
+	tests := []struct {
+		name     string
+		handler  func(ctx context.Context, e email) error
+		attempts int
+		lastErr  string
+	}{
+		{"attempts exhausted", func(context.Context, email) error { return errors.New("boom") }, 3, "boom"},
+		{"permanent error", func(context.Context, email) error { return jobs.Permanent(errors.New("bad address")) }, 1, "bad address"},
+		{"panic", func(context.Context, email) error { panic("nil map") }, 3, "panic: nil map"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := jobs.NewMemoryStore()
+			q := newQueue(t, store, jobs.Options{MaxAttempts: 3})
+			jobs.Handle(q, sendEmail, tt.handler)
+			q.Start(context.Background())
+
+			job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{})
+			dead := waitFor(t, store, job.ID, jobs.StateDead)
+			if dead.Attempts != tt.attempts || dead.LastError != tt.lastErr {
+				t.Errorf("dead job attempts, error = %d, %q; want %d, %q", dead.Attempts, dead.LastError, tt.attempts, tt.lastErr)
+			}
+
+			letters, err := store.List(context.Background(), jobs.StateDead, repository.ListOptions{})
+			if err != nil || len(letters) != 1 {
+				t.Errorf("List(dead) = %d jobs, %v; want 1", len(letters), err)
+			}
+		})
+	}
+}
+
+func TestQueueUnknownKindIsDeadLettered(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{})
+	q.Start(context.Background())
+
+	job, _ := jobs.Enqueue(context.Background(), q, jobs.Type[email]("unknown"), email{})
+	dead := waitFor(t, store, job.ID, jobs.StateDead)
+	if !strings.Contains(dead.LastError, "no handler") {
+		t.Errorf("last error = %q, want a missing handler error", dead.LastError)
+	}
+}
+
+func TestQueueBackoff(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{MinBackoff: time.Hour, MaxBackoff: 90 * time.Minute})
+	jobs.Handle(q, sendEmail, func(context.Context, email) error { return errors.New("boom") })
+	q.Start(context.Background())
+
+	start := time.Now()
+	job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		got, _ := store.Get(context.Background(), job.ID)
+		if got.State == jobs.StatePending && got.Attempts == 1 {
+			if delay := got.RunAt.Sub(start); delay < time.Hour || delay > time.Hour+time.Minute {
+				t.Errorf("first retry in %v, want about 1h", delay)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job not rescheduled: %+v", got)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestQueueRunAt(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{})
+	ran := make(chan time.Time, 1)
+	jobs.Handle(q, sendEmail, func(context.Context, email) error {
+		ran <- time.Now()
+		return nil
+	})
+	q.Start(context.Background())
+
+	runAt := time.Now().Add(50 * time.Millisecond)
+	if _, err := jobs.Enqueue(context.Background(), q, sendEmail, email{}, jobs.RunAt(runAt)); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if at := <-ran; at.Before(runAt) {
+		t.Errorf("job ran at %v, before its run-at time %v", at, runAt)
+	}
+}
+
+func TestQueueWorkerConcurrency(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{Workers: 3})
+	var (
+		running, peak atomic.Int32
+		release       = make(chan struct{})
+		wg            sync.WaitGroup
+	)
+	jobs.Handle(q, sendEmail, func(context.Context, email) error {
+		defer wg.Done()
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		return nil
+	})
+
+	const total = 9
+	wg.Add(total)
+	for range total {
+		jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	}
+	q.Start(context.Background())
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if p := peak.Load(); p != 3 {
+		t.Errorf("peak concurrency = %d, want 3", p)
+	}
+}
+
+func TestQueueStopWaitsForRunningJobs(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := jobs.New(store, jobs.Options{PollInterval: 5 * time.Millisecond}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	started := make(chan struct{})
+	jobs.Handle(q, sendEmail, func(context.Context, email) error {
+		close(started)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	q.Start(context.Background())
+	job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	<-started
+
+	if err := q.Stop(context.Background()); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if got, _ := store.Get(context.Background(), job.ID); got.State != jobs.StateDone {
+		t.Errorf("job state after Stop = %s, want done", got.State)
+	}
+}
+
+func TestQueueStopDeadlineCancelsJobs(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := jobs.New(store, jobs.Options{PollInterval: 5 * time.Millisecond}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	started := make(chan struct{})
+	cancelled := make(chan struct{})
+	jobs.Handle(q, sendEmail, func(ctx context.Context, _ email) error {
+		close(started)
+		<-ctx.Done()
+		close(cancelled)
+		return ctx.Err()
+	})
+	q.Start(context.Background())
+	jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want DeadlineExceeded", err)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("running job was not cancelled")
+	}
+}
+
+// outcomeCounter counts the outcomes a queue records
+type outcomeCounter struct {
+	jobs.Store
+	outcomes atomic.Int32
+}
+
+func (s *outcomeCounter) Complete(ctx context.Context, id int64, attempt int) error {
+	s.outcomes.Add(1)
+	return s.Store.Complete(ctx, id, attempt)
+}
+
+func (s *outcomeCounter) Retry(ctx context.Context, id int64, attempt int, runAt time.Time, lastErr string) error {
+	s.outcomes.Add(1)
+	return s.Store.Retry(ctx, id, attempt, runAt, lastErr)
+}
+
+func (s *outcomeCounter) Kill(ctx context.Context, id int64, attempt int, lastErr string) error {
+	s.outcomes.Add(1)
+	return s.Store.Kill(ctx, id, attempt, lastErr)
+}
+
+func TestQueueStopLeavesStoreAloneAfterDeadline(t *testing.T) {
+	store := &outcomeCounter{Store: jobs.NewMemoryStore()}
+	q := jobs.New(store, jobs.Options{PollInterval: 5 * time.Millisecond}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	started, release, finished := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	jobs.Handle(q, sendEmail, func(context.Context, email) error {
+		defer close(finished)
+		close(started)
+		<-release // ignores cancellation
+		return nil
+	})
+	q.Start(context.Background())
+	job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := q.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Stop() error = %v, want DeadlineExceeded", err)
+	}
+	close(release)
+	<-finished
+	time.Sleep(10 * time.Millisecond)
+
+	if n := store.outcomes.Load(); n != 0 {
+		t.Errorf("outcomes recorded after Stop returned = %d, want 0", n)
+	}
+	if got, _ := store.Get(context.Background(), job.ID); got.State != jobs.StateRunning {
+		t.Errorf("job state = %s, want running until its lease ends", got.State)
+	}
+}
+
+func TestQueueMetrics(t *testing.T) {
+	store := jobs.NewMemoryStore()
+	q := newQueue(t, store, jobs.Options{})
+	reg := prometheus.NewRegistry()
+	if err := q.RegisterMetrics(reg); err != nil {
+		t.Fatalf("RegisterMetrics() error = %v", err)
+	}
+	jobs.Handle(q, sendEmail, func(context.Context, email) error { return nil })
+	q.Start(context.Background())
+
+	job, _ := jobs.Enqueue(context.Background(), q, sendEmail, email{})
+	waitFor(t, store, job.ID, jobs.StateDone)
+
+	// The counter is bumped after the outcome is stored
+	want := `
+# HELP backend_jobs_processed_total Job attempts finished, by kind and outcome (succeeded, retried, dead).
+# TYPE backend_jobs_processed_total counter
+backend_jobs_processed_total{kind="send_email",outcome="succeeded"} 1
+`
+	deadline := time.Now().Add(time.Second)
+	for {
+		err := testutil.GatherAndCompare(reg, strings.NewReader(want), "backend_jobs_processed_total")
+		if err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("metrics: %v", err)
+		}
+		time.Sleep(2 * time.Millisecond)
+	}
+}
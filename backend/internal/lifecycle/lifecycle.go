@@ -0,0 +1,157 @@
+// Package lifecycle starts server components in order and stops them in
+// reverse order within a shutdown deadline
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrAlreadyStarted is returned when Start is called twice
+var ErrAlreadyStarted = errors.New("lifecycle: already started")
+
+// Hook is a component managed by the lifecycle. Either function may be nil.
+// Start must not block: long-running work belongs in a goroutine that Stop
+// ends. Stop should return once the component has released its resources
+// or ctx is done.
+type Hook struct {
+	Name  string
+	Start func(ctx context.Context) error
+	Stop  func(ctx context.Context) error
+}
+
+// Result reports how one component stopped
+type Result struct {
+	Name     string
+	Err      error
+	Duration time.Duration
+}
+
+// Clean reports whether the component stopped without error in time
+func (r Result) Clean() bool {
+	return r.Err == nil
+}
+
+// Manager runs hooks in registration order and stops them in reverse
+type Manager struct {
+	logger          *slog.Logger
+	shutdownTimeout time.Duration
+
+	mu      sync.Mutex
+	hooks   []Hook
+	started int
+	running bool
+}
+
+// New creates an empty manager that reports progress to logger.
+// shutdownTimeout bounds the stop hooks run when Start fails.
+func New(logger *slog.Logger, shutdownTimeout time.Duration) *Manager {
+	return &Manager{logger: logger, shutdownTimeout: shutdownTimeout}
+}
+
+// Append registers a hook. Hooks start in the order they are appended.
+func (m *Manager) Append(h Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, h)
+}
+
+// Start runs every start hook in order. When one fails, the components
+// started so far are stopped in reverse order within the shutdown timeout
+// and the error is returned.
+func (m *Manager) Start(ctx context.Context) error {
+	m.mu.Lock()
+	if m.running {
+		m.mu.Unlock()
+		return ErrAlreadyStarted
+	}
+	m.running = true
+	hooks := m.hooks
+	m.mu.Unlock()
+
+	for i, h := range hooks {
+		if h.Start != nil {
+			if err := h.Start(ctx); err != nil {
+				m.setStarted(i)
+				err = fmt.Errorf("lifecycle: start %s: %w", h.Name, err)
+				stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), m.shutdownTimeout)
+				_, stopErr := m.Stop(stopCtx)
+				cancel()
+				return errors.Join(err, stopErr)
+			}
+			m.logger.Debug("component started", "component", h.Name)
+		}
+		m.setStarted(i + 1)
+	}
+	return nil
+}
+
+// Stop runs the stop hooks of started components in reverse order. Every
+// hook shares the deadline of ctx; a hook still running at the deadline is
+// abandoned and reported with ctx's error. Stop returns one result per
+// stopped component and the joined errors of those that did not stop
+// cleanly.
+func (m *Manager) Stop(ctx context.Context) ([]Result, error) {
+	m.mu.Lock()
+	hooks := m.hooks[:m.started]
+	m.started = 0
+	m.mu.Unlock()
+
+	results := make([]Result, 0, len(hooks))
+	var errs []error
+	for i := len(hooks) - 1; i >= 0; i-- {
+		h := hooks[i]
+		if h.Stop == nil {
+			continue
+		}
+
+		start := time.Now()
+		err := stop(ctx, h)
+		result := Result{Name: h.Name, Err: err, Duration: time.Since(start)}
+		results = append(results, result)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("lifecycle: stop %s: %w", h.Name, err))
+			m.logger.Error("component stopped", "component", h.Name, "clean", false,
+				"duration_ms", result.Duration.Milliseconds(), "error", err)
+		} else {
+			m.logger.Info("component stopped", "component", h.Name, "clean", true,
+				"duration_ms", result.Duration.Milliseconds())
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+func (m *Manager) setStarted(n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = n
+}
+
+// stop runs one stop hook, giving up when ctx is done first
+func stop(ctx context.Context, h Hook) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- h.Stop(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,154 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+)
+
+// recorder builds hooks that append their events to a shared log
+type recorder struct {
+	events []string
+}
+
+func (r *recorder) hook(name string, startErr, stopErr error) Hook {
+	return Hook{
+		Name: name,
+		Start: func(context.Context) error {
+			r.events = append(r.events, "start "+name)
+			return startErr
+		},
+		Stop: func(context.Context) error {
+			r.events = append(r.events, "stop "+name)
+			return stopErr
+		},
+	}
+}
+
+func newManager() *Manager {
+	return New(slog.New(slog.NewTextHandler(io.Discard, nil)), 50*time.Millisecond)
+}
+
+func TestStartAndStopOrder(t *testing.T) {
+	var rec recorder
+	m := newManager()
+	m.Append(rec.hook("db", nil, nil))
+	m.Append(rec.hook("broker", nil, nil))
+	m.Append(Hook{Name: "no-op"})
+	m.Append(rec.hook("http", nil, nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := m.Start(context.Background()); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("Expected ErrAlreadyStarted, got %v", err)
+	}
+
+	results, err := m.Stop(context.Background())
+	if err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	want := []string{"start db", "start broker", "start http", "stop http", "stop broker", "stop db"}
+	if !slices.Equal(rec.events, want) {
+		t.Errorf("Events = %v, want %v", rec.events, want)
+	}
+	if len(results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(results))
+	}
+	for _, r := range results {
+		if !r.Clean() {
+			t.Errorf("Expected %s to stop cleanly, got %v", r.Name, r.Err)
+		}
+	}
+}
+
+func TestStartFailureStopsStartedComponents(t *testing.T) {
+	var rec recorder
+	boom := errors.New("boom")
+	m := newManager()
+	m.Append(rec.hook("db", nil, nil))
+	m.Append(rec.hook("broker", boom, nil))
+	m.Append(rec.hook("http", nil, nil))
+
+	if err := m.Start(context.Background()); !errors.Is(err, boom) {
+		t.Fatalf("Expected start error, got %v", err)
+	}
+
+	want := []string{"start db", "start broker", "stop db"}
+	if !slices.Equal(rec.events, want) {
+		t.Errorf("Events = %v, want %v", rec.events, want)
+	}
+}
+
+func TestStartFailureRollbackIsBounded(t *testing.T) {
+	boom := errors.New("boom")
+	m := newManager()
+	m.Append(Hook{
+		Name: "stuck",
+		Stop: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+	m.Append(Hook{Name: "broken", Start: func(context.Context) error { return boom }})
+
+	start := time.Now()
+	err := m.Start(context.Background())
+	if !errors.Is(err, boom) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected start error and stop deadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Start() took %v to roll back, want it bounded by the shutdown timeout", elapsed)
+	}
+}
+
+func TestStopReportsUncleanComponents(t *testing.T) {
+	var rec recorder
+	failed := errors.New("flush failed")
+	m := newManager()
+	m.Append(Hook{
+		Name: "stuck",
+		Stop: func(context.Context) error {
+			time.Sleep(time.Second)
+			return nil
+		},
+	})
+	m.Append(rec.hook("worker", nil, failed))
+	m.Append(rec.hook("db", nil, nil))
+
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := m.Stop(ctx)
+	if !errors.Is(err, failed) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected joined stop errors, got %v", err)
+	}
+
+	clean := make(map[string]bool)
+	for _, r := range results {
+		clean[r.Name] = r.Clean()
+	}
+	if len(clean) != 3 || clean["stuck"] || clean["worker"] || !clean["db"] {
+		t.Errorf("Unexpected results %+v", results)
+	}
+}
+
+func TestStopRecoversPanics(t *testing.T) {
+	m := newManager()
+	m.Append(Hook{Name: "panicky", Stop: func(context.Context) error { panic("oops") }})
+	if err := m.Start(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Stop(context.Background()); err == nil {
+		t.Error("Expected panic to be reported as an error")
+	}
+}
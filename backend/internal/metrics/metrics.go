@@ -0,0 +1,109 @@
+// Package metrics exposes Prometheus metrics for the server and its components
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/version"
+)
+
+// Namespace prefixes every metric exported by the backend
+const Namespace = "backend"
+
+// Instrumented is implemented by components that export their own metrics,
+// such as stores, brokers and limiters
+type Instrumented interface {
+	RegisterMetrics(reg prometheus.Registerer) error
+}
+
+// Metrics owns the Prometheus registry and the HTTP server metrics
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+// New creates a registry with Go runtime, process and HTTP metrics
+func New() *Metrics {
+	reg := prometheus.NewRegistry()
+	m := &Metrics{
+		registry: reg,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "HTTP requests processed, by method, route template and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency, by method, route template and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: Namespace,
+			Subsystem: "http",
+			Name:      "requests_in_flight",
+			Help:      "HTTP requests currently being served.",
+		}),
+	}
+
+	buildInfo := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "build_info",
+		Help:      "Build metadata of the running binary; the value is always 1.",
+	}, []string{"version", "commit", "go_version"})
+	info := version.Get()
+	buildInfo.WithLabelValues(info.Version, info.Commit, info.GoVersion).Set(1)
+
+	reg.MustRegister(
+		buildInfo,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.inFlight,
+	)
+	return m
+}
+
+// Registerer lets components register their own collectors
+func (m *Metrics) Registerer() prometheus.Registerer {
+	return m.registry
+}
+
+// Register asks each component to register its metrics
+func (m *Metrics) Register(components ...Instrumented) error {
+	for _, c := range components {
+		if err := c.RegisterMetrics(m.registry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Handler serves the registry in the Prometheus text format
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// RequestStarted marks a request as in flight
+func (m *Metrics) RequestStarted() {
+	m.inFlight.Inc()
+}
+
+// RequestFinished records a completed request
+func (m *Metrics) RequestFinished(method, route string, status int, elapsed time.Duration) {
+	m.inFlight.Dec()
+	code := strconv.Itoa(status)
+	m.requests.WithLabelValues(method, route, code).Inc()
+	m.duration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeComponent struct{}
+
+func (fakeComponent) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: Namespace,
+		Name:      "fake_items",
+		Help:      "Items held by the fake component.",
+	}, func() float64 { return 3 }))
+}
+
+func scrape(t *testing.T, m *Metrics) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	m.Handler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", w.Code)
+	}
+	body, _ := io.ReadAll(w.Body)
+	return string(body)
+}
+
+func TestMetricsExposition(t *testing.T) {
+	m := New()
+	if err := m.Register(fakeComponent{}); err != nil {
+		t.Fatalf("Expected component to register, got %v", err)
+	}
+
+	m.RequestStarted()
+	m.RequestFinished("GET", "/api/v1/ping", 200, 15*time.Millisecond)
+	m.RequestStarted()
+
+	out := scrape(t, m)
+	expected := []string{
+		`backend_http_requests_total{method="GET",route="/api/v1/ping",status="200"} 1`,
+		`backend_http_request_duration_seconds_bucket{method="GET",route="/api/v1/ping",status="200",le="0.025"} 1`,
+		`backend_http_requests_in_flight 1`,
+		`backend_fake_items 3`,
+		`backend_build_info{commit=`,
+		`go_goroutines`,
+	}
+	for _, want := range expected {
+		if !strings.Contains(out, want) {
+			t.Errorf("Expected metrics output to contain %q", want)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	m := New()
+	if err := m.Register(fakeComponent{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Register(fakeComponent{}); err == nil {
+		t.Error("Expected registering the same metric twice to fail")
+	}
+}
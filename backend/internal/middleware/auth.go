@@ -0,0 +1,86 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+)
+
+// ClaimsKey is the gin context key holding the authenticated *auth.Claims
+const ClaimsKey = "auth.claims"
+
+// Auth middleware requires a valid bearer access token and stores its claims
+// on both the gin context and the request context
+func Auth(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token, ok := bearerToken(c)
+		if !ok {
+			c.Header("WWW-Authenticate", `Bearer realm="api"`)
+			problem.Write(c, problem.New(http.StatusUnauthorized, "missing bearer token"))
+			return
+		}
+
+		claims, err := tokens.Parse(token, auth.TokenTypeAccess)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer realm="api", error="invalid_token"`)
+			problem.Write(c, problem.New(http.StatusUnauthorized, tokenErrorMessage(err)))
+			return
+		}
+		setClaims(c, claims)
+		c.Next()
+	}
+}
+
+// OptionalAuth stores the claims of a valid bearer access token like Auth
+// but lets requests without one through anonymously. It goes in front of
+// middleware such as rate limiting that treats known users differently;
+// routes that require a user still need Auth.
+func OptionalAuth(tokens *auth.TokenManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token, ok := bearerToken(c); ok {
+			if claims, err := tokens.Parse(token, auth.TokenTypeAccess); err == nil {
+				setClaims(c, claims)
+			}
+		}
+		c.Next()
+	}
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+func setClaims(c *gin.Context, claims *auth.Claims) {
+	c.Set(ClaimsKey, claims)
+	c.Request = c.Request.WithContext(auth.NewContext(c.Request.Context(), claims))
+}
+
+// Claims returns the claims stored by the Auth middleware
+func Claims(c *gin.Context) (*auth.Claims, bool) {
+	value, ok := c.Get(ClaimsKey)
+	if !ok {
+		return nil, false
+	}
+	claims, ok := value.(*auth.Claims)
+	return claims, ok
+}
+
+func tokenErrorMessage(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrTokenExpired):
+		return "token expired"
+	case errors.Is(err, auth.ErrTokenRevoked):
+		return "token revoked"
+	default:
+		return "invalid token"
+	}
+}
@@ -0,0 +1,256 @@
+package middleware
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+// CompressOptions configures the compression middleware
+type CompressOptions struct {
+	// MinSize is the smallest body worth compressing, in bytes
+	MinSize int
+	// ContentTypes lists compressible media types; "text/*" matches every
+	// subtype
+	ContentTypes []string
+}
+
+// DefaultCompressOptions returns the options used by the server
+func DefaultCompressOptions() CompressOptions {
+	return CompressOptions{
+		MinSize: 1024,
+		ContentTypes: []string{
+			"application/json",
+			"application/problem+json",
+			"application/javascript",
+			"application/xml",
+			"image/svg+xml",
+			"text/*",
+		},
+	}
+}
+
+// encodings are the supported content codings in order of preference
+var encodings = []string{"br", "gzip"}
+
+var (
+	gzipWriters   = sync.Pool{New: func() any { return gzip.NewWriter(io.Discard) }}
+	brotliWriters = sync.Pool{New: func() any { return brotli.NewWriterLevel(io.Discard, 4) }}
+)
+
+// Compress encodes responses with brotli or gzip as negotiated by
+// Accept-Encoding. Bodies smaller than MinSize, media types not listed in
+// ContentTypes and responses that already carry a Content-Encoding are sent
+// as they are.
+func Compress(opts CompressOptions) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, opts: &opts, encoding: encoding}
+		c.Writer = w
+		c.Next()
+		w.close()
+		c.Writer = w.ResponseWriter
+	}
+}
+
+// negotiateEncoding picks the preferred supported coding with a non-zero
+// quality in an Accept-Encoding header, or "" for identity
+func negotiateEncoding(header string) string {
+	if header == "" {
+		return ""
+	}
+	quality := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		quality[strings.ToLower(strings.TrimSpace(name))] = q
+	}
+
+	best, bestQ := "", 0.0
+	for _, enc := range encodings {
+		q, ok := quality[enc]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = enc, q
+		}
+	}
+	return best
+}
+
+// compressWriter buffers the start of the body until it knows whether the
+// response is worth compressing, then either compresses or passes it through
+type compressWriter struct {
+	gin.ResponseWriter
+	opts     *CompressOptions
+	encoding string
+
+	buf     bytes.Buffer
+	decided bool
+	encoder io.WriteCloser
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if !w.decided {
+		w.buf.Write(data)
+		if w.buf.Len() < w.opts.MinSize {
+			return len(data), nil
+		}
+		return len(data), w.decide()
+	}
+	if w.encoder != nil {
+		return w.encoder.Write(data)
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeaderNow commits the headers, so whatever is buffered is too short
+// to compress
+func (w *compressWriter) WriteHeaderNow() {
+	if !w.decided {
+		w.passThrough()
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.passThrough()
+	}
+	if f, ok := w.encoder.(interface{ Flush() error }); ok {
+		f.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Written reports true once anything was written, buffered or not, so gin
+// does not write headers again for an empty body
+func (w *compressWriter) Written() bool {
+	return w.buf.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// decide starts compressing when the response is eligible, then writes the
+// buffered bytes
+func (w *compressWriter) decide() error {
+	w.decided = true
+	header := w.Header()
+	if !w.compressible() {
+		return w.flushBuffer(w.ResponseWriter)
+	}
+
+	header.Set("Content-Encoding", w.encoding)
+	header.Del("Content-Length")
+	header.Del("Accept-Ranges")
+	if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		header.Set("ETag", "W/"+etag)
+	}
+
+	switch w.encoding {
+	case "br":
+		bw := brotliWriters.Get().(*brotli.Writer)
+		bw.Reset(w.ResponseWriter)
+		w.encoder = bw
+	default:
+		gw := gzipWriters.Get().(*gzip.Writer)
+		gw.Reset(w.ResponseWriter)
+		w.encoder = gw
+	}
+	return w.flushBuffer(w.encoder)
+}
+
+// passThrough sends the response uncompressed
+func (w *compressWriter) passThrough() {
+	w.decided = true
+	_ = w.flushBuffer(w.ResponseWriter)
+}
+
+func (w *compressWriter) flushBuffer(dst io.Writer) error {
+	w.varyIfCompressible()
+	if w.buf.Len() == 0 {
+		return nil
+	}
+	_, err := dst.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
+
+// varyIfCompressible tells caches the body depends on Accept-Encoding
+// whenever the media type could be compressed, even if this one was not
+func (w *compressWriter) varyIfCompressible() {
+	header := w.Header()
+	if !w.matchesContentType() {
+		return
+	}
+	for _, v := range header.Values("Vary") {
+		if strings.Contains(v, "Accept-Encoding") {
+			return
+		}
+	}
+	header.Add("Vary", "Accept-Encoding")
+}
+
+// close finishes the response once the handlers have returned
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.passThrough()
+		return
+	}
+	if w.encoder == nil {
+		return
+	}
+	w.encoder.Close()
+	switch e := w.encoder.(type) {
+	case *brotli.Writer:
+		brotliWriters.Put(e)
+	case *gzip.Writer:
+		gzipWriters.Put(e)
+	}
+	w.encoder = nil
+}
+
+func (w *compressWriter) compressible() bool {
+	status := w.Status()
+	return status != http.StatusNoContent && status != http.StatusNotModified &&
+		status >= http.StatusOK && w.Header().Get("Content-Encoding") == "" &&
+		w.matchesContentType()
+}
+
+func (w *compressWriter) matchesContentType() bool {
+	mediaType, _, err := mime.ParseMediaType(w.Header().Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	for _, allowed := range w.opts.ContentTypes {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(mediaType, prefix) {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+)
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"", ""},
+		{"gzip", "gzip"},
+		{"gzip, deflate, br", "br"},
+		{"br;q=0.5, gzip", "gzip"},
+		{"br;q=0, gzip;q=0", ""},
+		{"identity", ""},
+		{"*", "br"},
+		{"*;q=0.1, gzip;q=0", "br"},
+		{"GZIP", "gzip"},
+	}
+	for _, tt := range tests {
+		if got := negotiateEncoding(tt.header); got != tt.want {
+			t.Errorf("negotiateEncoding(%q) = %q, want %q", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestCompress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	large := strings.Repeat("compressible text ", 200)
+
+	router := gin.New()
+	router.Use(Compress(DefaultCompressOptions()))
+	router.GET("/large", func(c *gin.Context) { c.String(http.StatusOK, large) })
+	router.GET("/small", func(c *gin.Context) { c.JSON(http.StatusOK, gin.H{"ok": true}) })
+	router.GET("/binary", func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(large)) })
+	router.GET("/empty", func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	get := func(path, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("Accept-Encoding", acceptEncoding)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	decoders := map[string]func(io.Reader) (io.Reader, error){
+		"gzip": func(r io.Reader) (io.Reader, error) { return gzip.NewReader(r) },
+		"br":   func(r io.Reader) (io.Reader, error) { return brotli.NewReader(r), nil },
+	}
+	for encoding, decode := range decoders {
+		w := get("/large", encoding)
+		if got := w.Header().Get("Content-Encoding"); got != encoding {
+			t.Fatalf("Expected %s encoding, got %q", encoding, got)
+		}
+		if w.Header().Get("Vary") != "Accept-Encoding" {
+			t.Errorf("Expected Vary: Accept-Encoding, got %q", w.Header().Get("Vary"))
+		}
+		r, err := decode(w.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(r)
+		if err != nil || string(body) != large {
+			t.Errorf("Expected %s body to round-trip, got %d bytes, %v", encoding, len(body), err)
+		}
+	}
+
+	if w := get("/large", ""); w.Header().Get("Content-Encoding") != "" || w.Body.String() != large {
+		t.Error("Expected an uncompressed body without Accept-Encoding")
+	}
+
+	w := get("/small", "gzip")
+	if w.Header().Get("Content-Encoding") != "" || w.Body.String() != `{"ok":true}` {
+		t.Errorf("Expected a body below the minimum size to pass through, got %q", w.Body.String())
+	}
+	if w.Header().Get("Vary") != "Accept-Encoding" {
+		t.Error("Expected Vary on compressible responses even when they are small")
+	}
+
+	if w := get("/binary", "gzip"); w.Header().Get("Content-Encoding") != "" || w.Body.Len() != len(large) {
+		t.Error("Expected media types outside the list to pass through")
+	}
+
+	if w := get("/empty", "gzip"); w.Code != http.StatusNoContent || w.Body.Len() != 0 || w.Header().Get("Content-Encoding") != "" {
+		t.Errorf("Expected an empty 204, got %d with %d bytes", w.Code, w.Body.Len())
+	}
+}
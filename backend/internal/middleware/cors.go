@@ -1,22 +1,218 @@
 package middleware
 
 import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
 )
 
+// CORSOptions configures the CORS middleware
+type CORSOptions struct {
+	// AllowedOrigins lists exact origins such as "https://app.example.com",
+	// wildcard subdomain patterns such as "https://*.example.com", or "*".
+	// Origins allowed only through "*" get a literal "*" and never
+	// credentials, so no site can make credentialed requests.
+	AllowedOrigins []string
+	// AllowedMethods are returned on preflight responses
+	AllowedMethods []string
+	// AllowedHeaders limits which requested headers are echoed on preflight;
+	// empty or "*" allows any header the browser asks for
+	AllowedHeaders []string
+	// ExposedHeaders are readable by browser scripts on actual responses
+	ExposedHeaders []string
+	// AllowCredentials permits cookies and Authorization headers
+	AllowCredentials bool
+	// MaxAge tells browsers how long to cache a preflight response
+	MaxAge time.Duration
+}
+
+// DefaultCORSOptions returns the options used by the API for the given origins
+func DefaultCORSOptions(origins []string) CORSOptions {
+	return CORSOptions{
+		AllowedOrigins:   origins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Content-Length", "Accept-Encoding", "X-CSRF-Token", "Authorization", "Accept", "Origin", "Cache-Control", "X-Requested-With", "If-None-Match", IdempotencyKeyHeader, "traceparent", "tracestate"},
+		ExposedHeaders:   []string{RequestIDHeader, "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "ETag", IdempotentReplayedHeader},
+		AllowCredentials: true,
+		MaxAge:           12 * time.Hour,
+	}
+}
+
+// CORSPolicy is an origin allowlist whose origins can be replaced at runtime
+type CORSPolicy struct {
+	opts CORSOptions
+
+	mu       sync.RWMutex
+	origins  map[string]bool
+	patterns []originPattern
+	any      bool
+}
+
+// originPattern matches origins of the form scheme://<subdomain>suffix
+type originPattern struct {
+	prefix string
+	suffix string
+}
+
+// NewCORSPolicy creates a policy from the given options
+func NewCORSPolicy(opts CORSOptions) *CORSPolicy {
+	p := &CORSPolicy{opts: opts}
+	p.SetAllowedOrigins(opts.AllowedOrigins)
+	return p
+}
+
 // CORS middleware to handle Cross-Origin Resource Sharing
-func CORS() gin.HandlerFunc {
-	return gin.HandlerFunc(func(c *gin.Context) {
-		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(204)
+func CORS(opts CORSOptions) gin.HandlerFunc {
+	return NewCORSPolicy(opts).Middleware()
+}
+
+// SetAllowedOrigins replaces the origin allowlist
+func (p *CORSPolicy) SetAllowedOrigins(origins []string) {
+	exact := make(map[string]bool)
+	var patterns []originPattern
+	anyOrigin := false
+
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "":
+		case origin == "*":
+			anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			patterns = append(patterns, originPattern{prefix: prefix, suffix: suffix})
+		default:
+			exact[strings.TrimSuffix(origin, "/")] = true
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.origins = exact
+	p.patterns = patterns
+	p.any = anyOrigin
+}
+
+// Allowed reports whether a request from origin may read responses
+func (p *CORSPolicy) Allowed(origin string) bool {
+	allowed, _ := p.allow(origin)
+	return allowed
+}
+
+// allow reports whether origin is allowed and whether only "*" allows it
+func (p *CORSPolicy) allow(origin string) (allowed, wildcard bool) {
+	origin = strings.ToLower(origin)
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.origins[origin] {
+		return true, false
+	}
+	for _, pattern := range p.patterns {
+		if pattern.match(origin) {
+			return true, false
+		}
+	}
+	return p.any, p.any
+}
+
+// match requires at least one subdomain label in place of the wildcard
+func (o originPattern) match(origin string) bool {
+	if !strings.HasPrefix(origin, o.prefix) || !strings.HasSuffix(origin, o.suffix) {
+		return false
+	}
+	sub := origin[len(o.prefix) : len(origin)-len(o.suffix)]
+	return sub != "" && !strings.ContainsAny(sub, "/:") && !strings.HasPrefix(sub, ".") && !strings.HasSuffix(sub, ".")
+}
+
+// Middleware returns the gin handler enforcing the policy
+func (p *CORSPolicy) Middleware() gin.HandlerFunc {
+	exposed := strings.Join(p.opts.ExposedHeaders, ", ")
+	methods := strings.Join(p.opts.AllowedMethods, ", ")
+	maxAge := strconv.Itoa(int(p.opts.MaxAge.Seconds()))
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+
+		origin := c.GetHeader("Origin")
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+
+		if origin == "" {
+			c.Next()
+			return
+		}
+		allowed, wildcard := p.allow(origin)
+		if !allowed {
+			if preflight {
+				problem.Write(c, problem.New(http.StatusForbidden, "origin not allowed"))
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if wildcard {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+			if p.opts.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		if !preflight {
+			if exposed != "" {
+				header.Set("Access-Control-Expose-Headers", exposed)
+			}
+			c.Next()
 			return
 		}
 
-		c.Next()
-	})
+		if methods != "" {
+			header.Set("Access-Control-Allow-Methods", methods)
+		}
+		if requested := p.allowedHeaders(c.GetHeader("Access-Control-Request-Headers")); requested != "" {
+			header.Set("Access-Control-Allow-Headers", requested)
+		}
+		if p.opts.MaxAge > 0 {
+			header.Set("Access-Control-Max-Age", maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// allowedHeaders filters the headers requested on preflight by the allowlist
+func (p *CORSPolicy) allowedHeaders(requested string) string {
+	if requested == "" {
+		return ""
+	}
+
+	allowAll := len(p.opts.AllowedHeaders) == 0
+	allowed := make(map[string]bool, len(p.opts.AllowedHeaders))
+	for _, h := range p.opts.AllowedHeaders {
+		if h == "*" {
+			allowAll = true
+		}
+		allowed[http.CanonicalHeaderKey(h)] = true
+	}
+
+	var out []string
+	for _, h := range strings.Split(requested, ",") {
+		h = strings.TrimSpace(h)
+		if h != "" && (allowAll || allowed[http.CanonicalHeaderKey(h)]) {
+			out = append(out, h)
+		}
+	}
+	return strings.Join(out, ", ")
 }
@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+)
+
+func newCORSRouter(policy *CORSPolicy) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(policy.Middleware())
+	router.GET("/api/v1/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	opts := DefaultCORSOptions([]string{"http://localhost:3000", "https://*.example.com"})
+	opts.ExposedHeaders = []string{"X-Request-ID"}
+	router := newCORSRouter(NewCORSPolicy(opts))
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"http://localhost:3000", true},
+		{"https://app.example.com", true},
+		{"https://a.b.example.com", true},
+		{"https://example.com", false},
+		{"http://app.example.com", false},
+		{"https://evil-example.com", false},
+		{"https://app.example.com.evil.io", false},
+		{"http://localhost:4000", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+			req.Header.Set("Origin", tt.origin)
+			router.ServeHTTP(w, req)
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("Expected status 200, got %d", w.Code)
+			}
+			got := w.Header().Get("Access-Control-Allow-Origin")
+			if tt.allowed && got != tt.origin {
+				t.Errorf("Expected Allow-Origin %q, got %q", tt.origin, got)
+			}
+			if !tt.allowed && got != "" {
+				t.Errorf("Expected no Allow-Origin, got %q", got)
+			}
+			if w.Header().Get("Vary") != "Origin" {
+				t.Errorf("Expected Vary: Origin, got %q", w.Header().Get("Vary"))
+			}
+			if tt.allowed && w.Header().Get("Access-Control-Expose-Headers") != "X-Request-ID" {
+				t.Errorf("Expected exposed headers, got %q", w.Header().Get("Access-Control-Expose-Headers"))
+			}
+		})
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	opts := DefaultCORSOptions([]string{"http://localhost:3000"})
+	opts.AllowedHeaders = []string{"Content-Type", "Authorization"}
+	opts.MaxAge = 10 * time.Minute
+	router := newCORSRouter(NewCORSPolicy(opts))
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/ping", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-unknown")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("Expected status 204, got %d", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got != "content-type" {
+		t.Errorf("Expected only allowed requested headers, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Errorf("Expected max age 600, got %q", got)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Errorf("Expected credentials to be allowed, got %q", got)
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodOptions, "/api/v1/ping", nil)
+	req.Header.Set("Origin", "http://evil.test")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden {
+		t.Errorf("Expected status 403 for disallowed preflight, got %d", w.Code)
+	}
+	if got := w.Header().Get("Content-Type"); got != problem.ContentType {
+		t.Errorf("Expected a problem response for disallowed preflight, got content type %q", got)
+	}
+}
+
+func TestCORSSetAllowedOrigins(t *testing.T) {
+	policy := NewCORSPolicy(DefaultCORSOptions([]string{"http://localhost:3000"}))
+	if !policy.Allowed("http://localhost:3000") {
+		t.Fatal("Expected initial origin to be allowed")
+	}
+
+	policy.SetAllowedOrigins([]string{"https://app.example.com"})
+	if policy.Allowed("http://localhost:3000") {
+		t.Error("Expected replaced origin to be rejected")
+	}
+	if !policy.Allowed("https://app.example.com") {
+		t.Error("Expected new origin to be allowed")
+	}
+}
+
+func TestCORSWildcardNeverAllowsCredentials(t *testing.T) {
+	router := newCORSRouter(NewCORSPolicy(DefaultCORSOptions([]string{"*", "http://localhost:3000"})))
+
+	tests := []struct {
+		origin      string
+		allowOrigin string
+		credentials string
+	}{
+		{"https://evil.example", "*", ""},
+		{"http://localhost:3000", "http://localhost:3000", "true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/ping", nil)
+			req.Header.Set("Origin", tt.origin)
+			router.ServeHTTP(w, req)
+
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.allowOrigin {
+				t.Errorf("Expected Allow-Origin %q, got %q", tt.allowOrigin, got)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.credentials {
+				t.Errorf("Expected Allow-Credentials %q, got %q", tt.credentials, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ETag buffers successful GET responses, tags them with a weak ETag derived
+// from the body and answers 304 Not Modified when If-None-Match already
+// names it. Responses that set their own ETag keep it. Handlers behind this
+// middleware must not stream, as the whole body is held in memory.
+func ETag() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		w := &bufferedWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+
+		header := c.Writer.Header()
+		if c.Writer.Status() == http.StatusOK && w.body.Len() > 0 {
+			if header.Get("ETag") == "" {
+				sum := sha256.Sum256(w.body.Bytes())
+				header.Set("ETag", `W/"`+base64.RawURLEncoding.EncodeToString(sum[:16])+`"`)
+			}
+			if etagMatches(c.GetHeader("If-None-Match"), header.Get("ETag")) {
+				header.Del("Content-Type")
+				header.Del("Content-Length")
+				c.Writer.WriteHeader(http.StatusNotModified)
+				c.Writer.WriteHeaderNow()
+				return
+			}
+		}
+		if w.body.Len() > 0 {
+			_, _ = c.Writer.Write(w.body.Bytes())
+		}
+	}
+}
+
+// etagMatches applies the weak comparison of RFC 9110 to an If-None-Match
+// header
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" || etag == "" {
+		return false
+	}
+	if strings.TrimSpace(ifNoneMatch) == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds the body until the handlers have finished
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.body.WriteString(s)
+}
+
+// WriteHeaderNow is deferred until the body is known
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Written() bool {
+	return w.body.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Flush is ignored because flushing would commit the headers early
+func (w *bufferedWriter) Flush() {}
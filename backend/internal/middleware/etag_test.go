@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := `{"items":[1,2,3]}`
+
+	router := gin.New()
+	router.Use(ETag())
+	router.GET("/items", func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(body)) })
+	router.GET("/missing", func(c *gin.Context) { c.JSON(http.StatusNotFound, gin.H{"error": "missing"}) })
+	router.POST("/items", func(c *gin.Context) { c.Data(http.StatusCreated, "application/json", []byte(body)) })
+
+	do := func(method, path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := do(http.MethodGet, "/items", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || first.Body.String() != body {
+		t.Fatalf("Expected the body on the first request, got %d %q", first.Code, first.Body)
+	}
+	if !strings.HasPrefix(etag, `W/"`) {
+		t.Fatalf("Expected a weak ETag, got %q", etag)
+	}
+
+	for _, header := range []string{etag, strings.TrimPrefix(etag, "W/"), `"other", ` + etag, "*"} {
+		w := do(http.MethodGet, "/items", header)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: expected empty 304, got %d with %q", header, w.Code, w.Body)
+		}
+		if w.Header().Get("ETag") != etag {
+			t.Errorf("Expected the 304 to repeat the ETag, got %q", w.Header().Get("ETag"))
+		}
+	}
+
+	if w := do(http.MethodGet, "/items", `W/"stale"`); w.Code != http.StatusOK || w.Body.String() != body {
+		t.Errorf("Expected a stale ETag to get the body, got %d", w.Code)
+	}
+	if w := do(http.MethodGet, "/missing", "*"); w.Code != http.StatusNotFound || w.Header().Get("ETag") != "" {
+		t.Errorf("Expected errors to pass through untagged, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+	if w := do(http.MethodPost, "/items", ""); w.Code != http.StatusCreated || w.Header().Get("ETag") != "" {
+		t.Errorf("Expected POST to pass through untagged, got %d %q", w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/idempotency"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+)
+
+// Idempotency headers
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLen     = 255
+	// maxIdempotentBodySize bounds the body read into memory to fingerprint
+	// and replay a request
+	maxIdempotentBodySize = 1 << 20
+)
+
+// replayedHeaders are the response headers stored for replay besides the body
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// Idempotency makes POST requests carrying an Idempotency-Key safe to retry.
+// The first response for a key is stored for ttl and replayed to retries with
+// the same method, path and body. Keys are scoped to the client as chosen by
+// KeyByClient, so the middleware belongs behind Auth or OptionalAuth.
+// A key reused with a different request is rejected with 422, a retry
+// arriving while the first request still runs with 409 and a body larger
+// than maxIdempotentBodySize with 413. Server errors are not
+// stored so the client can retry them. Store errors are logged and the
+// request is processed without idempotency.
+func Idempotency(store idempotency.Store, ttl time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			problem.Write(c, problem.Validation(problem.FieldError{
+				Field:  IdempotencyKeyHeader,
+				Detail: "must be at most " + strconv.Itoa(maxIdempotencyKeyLen) + " characters",
+			}))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxIdempotentBodySize))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			problem.Write(c, problem.New(http.StatusRequestEntityTooLarge,
+				"the request body must be at most "+strconv.FormatInt(tooLarge.Limit, 10)+" bytes"))
+			return
+		}
+		if err != nil {
+			problem.Write(c, problem.New(http.StatusBadRequest, "the request body could not be read"))
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		key = KeyByClient(c) + ":" + key
+		stored, err := store.Reserve(ctx, key, fingerprint(c.Request, body), ttl)
+		switch {
+		case errors.Is(err, idempotency.ErrInFlight) || errors.Is(err, idempotency.ErrKeyReused):
+			problem.Write(c, err)
+			return
+		case err != nil:
+			logging.FromContext(ctx).Error("idempotency store failed", "error", err)
+			c.Next()
+			return
+		case stored != nil:
+			replay(c, stored)
+			return
+		}
+
+		// A panic leaves the key reserved unless it is released here
+		done := false
+		defer func() {
+			if !done {
+				release(c, store, key)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		done = true
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			release(c, store, key)
+			return
+		}
+		recorder.WriteHeaderNow()
+		if err := store.Complete(ctx, key, recorder.response()); err != nil {
+			logging.FromContext(ctx).Error("idempotency store failed", "error", err)
+		}
+	}
+}
+
+func release(c *gin.Context, store idempotency.Store, key string) {
+	if err := store.Release(c.Request.Context(), key); err != nil {
+		logging.FromContext(c.Request.Context()).Error("idempotency store failed", "error", err)
+	}
+}
+
+// fingerprint identifies a request by method, path and body
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.Path+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// replay writes a stored response
+func replay(c *gin.Context, res *idempotency.Response) {
+	for name, values := range res.Header {
+		for _, v := range values {
+			c.Writer.Header().Add(name, v)
+		}
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(res.Status)
+	_, _ = c.Writer.Write(res.Body)
+	c.Abort()
+}
+
+// responseRecorder copies the response body while passing it through
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseRecorder) response() idempotency.Response {
+	header := make(http.Header)
+	for _, name := range replayedHeaders {
+		if v := w.Header().Values(name); len(v) > 0 {
+			header[name] = v
+		}
+	}
+	return idempotency.Response{Status: w.Status(), Header: header, Body: w.body.Bytes()}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/idempotency"
+)
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := idempotency.NewMemoryStore()
+
+	calls := 0
+	router := gin.New()
+	router.POST("/messages", Idempotency(store, time.Hour), func(c *gin.Context) {
+		calls++
+		if c.Query("fail") != "" {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Header("Location", "/messages/1")
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	post := func(target, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	first := post("/messages", "abc", `{"content":"hi"}`)
+	if first.Code != http.StatusCreated || first.Header().Get(IdempotentReplayedHeader) != "" {
+		t.Fatalf("Expected the first request to be processed, got %d", first.Code)
+	}
+
+	retry := post("/messages", "abc", `{"content":"hi"}`)
+	if calls != 1 {
+		t.Fatalf("Expected the retry not to reach the handler, got %d calls", calls)
+	}
+	if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() {
+		t.Errorf("Expected the retry to replay %d %s, got %d %s", first.Code, first.Body, retry.Code, retry.Body)
+	}
+	if retry.Header().Get(IdempotentReplayedHeader) != "true" || retry.Header().Get("Location") != "/messages/1" {
+		t.Errorf("Expected replayed headers, got %v", retry.Header())
+	}
+
+	if w := post("/messages", "abc", `{"content":"bye"}`); w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("Expected 422 for a reused key with another body, got %d", w.Code)
+	}
+
+	if w := post("/messages", "", `{"content":"hi"}`); w.Code != http.StatusCreated || calls != 2 {
+		t.Errorf("Expected requests without a key to be processed, got %d after %d calls", w.Code, calls)
+	}
+
+	post("/messages?fail=1", "retry-me", `{}`)
+	post("/messages?fail=1", "retry-me", `{}`)
+	if calls != 4 {
+		t.Errorf("Expected server errors not to be replayed, got %d calls", calls)
+	}
+
+	if w := post("/messages", strings.Repeat("k", 256), `{}`); w.Code != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an oversized key, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyInFlight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	started, release := make(chan struct{}), make(chan struct{})
+
+	router := gin.New()
+	router.POST("/", Idempotency(idempotency.NewMemoryStore(), time.Hour), func(c *gin.Context) {
+		close(started)
+		<-release
+		c.Status(http.StatusCreated)
+	})
+
+	post := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+		req.Header.Set(IdempotencyKeyHeader, "abc")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- post() }()
+	<-started
+
+	if w := post(); w.Code != http.StatusConflict {
+		t.Errorf("Expected 409 while the first request is in flight, got %d", w.Code)
+	}
+	close(release)
+	if w := <-done; w.Code != http.StatusCreated {
+		t.Errorf("Expected the first request to finish with 201, got %d", w.Code)
+	}
+}
+
+func TestIdempotencyRejectsLargeBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/", Idempotency(idempotency.NewMemoryStore(), time.Hour), func(c *gin.Context) {
+		t.Error("Expected the handler not to run for an oversized body")
+	})
+
+	body := strings.NewReader(strings.Repeat("x", maxIdempotentBodySize+1))
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(IdempotencyKeyHeader, "abc")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected 413 for an oversized body, got %d", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/problem"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+)
+
+// RateLimitKeyFunc picks the identity a request is rate limited by
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// KeyByIP limits each client IP separately
+func KeyByIP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// KeyByClient limits authenticated users by user ID and everyone else by IP.
+// Users are only recognised behind the Auth or OptionalAuth middleware, so
+// only verified identities get their own bucket; anything else the client
+// sends could be changed on every request to get a fresh one.
+func KeyByClient(c *gin.Context) string {
+	if claims, ok := Claims(c); ok {
+		return "user:" + claims.Subject
+	}
+	return KeyByIP(c)
+}
+
+// RateLimit rejects requests with 429 once the client's bucket for policy is empty.
+// Store errors are logged and the request is let through.
+func RateLimit(store ratelimit.Store, policy *ratelimit.Policy, key RateLimitKeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		limit := policy.Limit()
+		if limit.Unlimited() {
+			c.Next()
+			return
+		}
+
+		res, err := store.Take(c.Request.Context(), policy.Name()+":"+key(c), limit)
+		if err != nil {
+			logging.FromContext(c.Request.Context()).Error("rate limit store failed", "policy", policy.Name(), "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("RateLimit-Reset", ceilSeconds(res.Reset))
+		c.Header("RateLimit-Policy", strconv.Itoa(res.Limit)+";w="+ceilSeconds(time.Duration(float64(res.Limit)/limit.Rate*float64(time.Second))))
+
+		if !res.Allowed {
+			c.Header("Retry-After", ceilSeconds(res.RetryAfter))
+			problem.Write(c, problem.New(http.StatusTooManyRequests, "rate limit exceeded"))
+			return
+		}
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/ratelimit"
+)
+
+func TestRateLimit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := ratelimit.NewMemoryStore()
+	policy := ratelimit.NewPolicy("test", ratelimit.Limit{Rate: 0.5, Burst: 2})
+
+	tokens := auth.NewTokenManager(auth.NewKeySet("test-secret"), auth.TokenOptions{Issuer: "test", AccessTTL: time.Minute, RefreshTTL: time.Hour})
+	pair, err := tokens.Issue(&models.User{ID: 7, Email: "alice@example.com", Role: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := gin.New()
+	router.GET("/", OptionalAuth(tokens), RateLimit(store, policy, KeyByClient), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	do := func(header, value string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	for i := 0; i < 2; i++ {
+		if w := do("", ""); w.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to pass, got %d", i+1, w.Code)
+		}
+	}
+
+	w := do("", "")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status 429, got %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("Expected Retry-After 2, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Limit"); got != "2" {
+		t.Errorf("Expected RateLimit-Limit 2, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Remaining"); got != "0" {
+		t.Errorf("Expected RateLimit-Remaining 0, got %q", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "4" {
+		t.Errorf("Expected RateLimit-Reset 4, got %q", got)
+	}
+
+	// Headers the server does not verify share the IP's bucket, so rotating
+	// them does not buy more requests
+	for _, key := range []string{"key-1", "key-2", "key-3"} {
+		if w := do("X-API-Key", key); w.Code != http.StatusTooManyRequests {
+			t.Errorf("Expected status 429 with rotated X-API-Key %s, got %d", key, w.Code)
+		}
+	}
+	if w := do("Authorization", "Bearer forged"); w.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 with an invalid bearer token, got %d", w.Code)
+	}
+
+	// A verified user gets their own bucket even from the same IP
+	if w := do("Authorization", "Bearer "+pair.AccessToken); w.Code != http.StatusOK {
+		t.Errorf("Expected authenticated user to pass, got %d", w.Code)
+	}
+
+	// Disabling the policy lets everything through
+	policy.SetLimit(ratelimit.Limit{})
+	if w := do("", ""); w.Code != http.StatusOK {
+		t.Errorf("Expected disabled policy to pass, got %d", w.Code)
+	}
+}
+
+func TestRateLimitIgnoresUntrustedForwardedFor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	policy := ratelimit.NewPolicy("test", ratelimit.Limit{Rate: 0.5, Burst: 1})
+
+	newRouter := func(trusted []string) *gin.Engine {
+		router := gin.New()
+		if err := router.SetTrustedProxies(trusted); err != nil {
+			t.Fatal(err)
+		}
+		router.GET("/", RateLimit(ratelimit.NewMemoryStore(), policy, KeyByIP), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	do := func(router *gin.Engine, forwardedFor string) int {
+		// httptest requests come from 192.0.2.1
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	// With no trusted proxies a spoofed X-Forwarded-For shares the peer's bucket
+	router := newRouter(nil)
+	if code := do(router, ""); code != http.StatusOK {
+		t.Fatalf("Expected first request to pass, got %d", code)
+	}
+	if code := do(router, "203.0.113.9"); code != http.StatusTooManyRequests {
+		t.Errorf("Expected status 429 with a spoofed X-Forwarded-For, got %d", code)
+	}
+
+	// Behind a trusted proxy each forwarded client gets its own bucket
+	router = newRouter([]string{"192.0.2.1"})
+	if code := do(router, "203.0.113.9"); code != http.StatusOK {
+		t.Fatalf("Expected first forwarded client to pass, got %d", code)
+	}
+	if code := do(router, "203.0.113.10"); code != http.StatusOK {
+		t.Errorf("Expected second forwarded client to pass, got %d", code)
+	}
+}
@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+)
+
+func TestRequestLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := logging.New(&buf, slog.LevelInfo)
+
+	router := gin.New()
+	router.Use(RequestLogger(logger))
+	router.GET("/items/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context()).Info("handler")
+		c.String(http.StatusOK, "ok")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	req.Header.Set(RequestIDHeader, "client-id-1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if got := w.Header().Get(RequestIDHeader); got != "client-id-1" {
+		t.Errorf("Expected propagated request ID, got %q", got)
+	}
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 log lines, got %d: %s", len(lines), buf.String())
+	}
+
+	var handlerLine, requestLine map[string]any
+	if err := json.Unmarshal(lines[0], &handlerLine); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(lines[1], &requestLine); err != nil {
+		t.Fatal(err)
+	}
+
+	if handlerLine["request_id"] != "client-id-1" {
+		t.Errorf("Expected handler log to carry request ID, got %v", handlerLine)
+	}
+	expected := map[string]any{
+		"msg":        "request",
+		"request_id": "client-id-1",
+		"method":     "GET",
+		"route":      "/items/:id",
+		"path":       "/items/42",
+		"status":     float64(200),
+		"bytes":      float64(2),
+	}
+	for key, want := range expected {
+		if requestLine[key] != want {
+			t.Errorf("Expected %s=%v, got %v", key, want, requestLine[key])
+		}
+	}
+	if _, ok := requestLine["latency_ms"]; !ok {
+		t.Error("Expected latency_ms to be logged")
+	}
+}
+
+func TestRequestLoggerGeneratesID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+
+	router := gin.New()
+	router.Use(RequestLogger(logging.New(&buf, slog.LevelInfo)))
+	router.GET("/", func(c *gin.Context) {
+		if logging.RequestID(c.Request.Context()) != RequestID(c) {
+			t.Error("Expected request context and gin context to share the ID")
+		}
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, header := range []string{"", "has spaces", string(bytes.Repeat([]byte("a"), 200))} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		got := w.Header().Get(RequestIDHeader)
+		if len(got) != 32 {
+			t.Errorf("Expected generated 32-char request ID for %q, got %q", header, got)
+		}
+	}
+}
@@ -0,0 +1,414 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Errors returned by the migrator
+var (
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrNoDownScript   = errors.New("migration has no down script")
+	ErrChecksum       = errors.New("applied migrations do not match their files")
+)
+
+// Record is a migration recorded as applied in schema_migrations.
+// An empty Checksum means the row predates checksum tracking.
+type Record struct {
+	Version   uint64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+// Driver stores migration state and runs scripts against a database
+type Driver interface {
+	// Lock blocks until no other migrator runs against the same database
+	Lock(ctx context.Context) error
+	Unlock(ctx context.Context) error
+	// Init creates the schema_migrations table if needed
+	Init(ctx context.Context) error
+	// Applied lists applied migrations ordered by version, and none when
+	// the schema_migrations table does not exist yet
+	Applied(ctx context.Context) ([]Record, error)
+	// Apply runs the script and records or removes m in a single transaction
+	Apply(ctx context.Context, m Migration, up bool) error
+	// SetApplied replaces the recorded state without running any scripts
+	SetApplied(ctx context.Context, records []Record) error
+}
+
+// Status describes one migration and whether it is applied
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Modified is set when the file changed after the migration was applied
+	Modified bool
+}
+
+// Drift describes an applied migration whose file was edited or removed
+type Drift struct {
+	Version uint64
+	Name    string
+	Applied string
+	Current string
+	Missing bool
+}
+
+func (d Drift) String() string {
+	if d.Missing {
+		return fmt.Sprintf("%06d_%s: file is missing", d.Version, d.Name)
+	}
+	return fmt.Sprintf("%06d_%s: checksum %.12s applied, file is now %.12s", d.Version, d.Name, d.Applied, d.Current)
+}
+
+// DriftError lists every applied migration that no longer matches its file
+type DriftError struct {
+	Drifts []Drift
+}
+
+func (e *DriftError) Error() string {
+	msg := "migrate: applied migrations do not match their files:"
+	for _, d := range e.Drifts {
+		msg += "\n  " + d.String()
+	}
+	return msg + "\nrestore the original files, or run force to accept the edits"
+}
+
+func (e *DriftError) Unwrap() error {
+	return ErrChecksum
+}
+
+// Migrator applies migrations through a driver
+type Migrator struct {
+	driver     Driver
+	migrations []Migration
+	out        io.Writer
+	dryRun     bool
+}
+
+// New creates a migrator for the loaded migrations. Progress is written to out.
+func New(driver Driver, migrations []Migration, out io.Writer) *Migrator {
+	if out == nil {
+		out = io.Discard
+	}
+	return &Migrator{driver: driver, migrations: migrations, out: out}
+}
+
+// SetDryRun makes Up, Down and Goto print the SQL they would run, in order,
+// and Force the state it would record, without executing anything, taking
+// the lock or creating schema_migrations
+func (m *Migrator) SetDryRun(enabled bool) {
+	m.dryRun = enabled
+}
+
+// Up applies the next n pending migrations, or all of them when n <= 0
+func (m *Migrator) Up(ctx context.Context, n int) error {
+	return m.locked(ctx, func(applied map[uint64]Record) error {
+		pending := m.pending(applied)
+		if n > 0 && n < len(pending) {
+			pending = pending[:n]
+		}
+		return m.run(ctx, pending, true)
+	})
+}
+
+// Down rolls back the last n applied migrations, or all of them when n <= 0
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.locked(ctx, func(applied map[uint64]Record) error {
+		done := m.applied(applied)
+		if n > 0 && n < len(done) {
+			done = done[:n]
+		}
+		return m.run(ctx, done, false)
+	})
+}
+
+// Goto migrates up or down until exactly the migrations up to version are applied.
+// Version 0 rolls everything back.
+func (m *Migrator) Goto(ctx context.Context, version uint64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migrate: %w %d", ErrUnknownVersion, version)
+	}
+	return m.locked(ctx, func(applied map[uint64]Record) error {
+		var down []Migration
+		for _, mig := range m.applied(applied) {
+			if mig.Version > version {
+				down = append(down, mig)
+			}
+		}
+		if err := m.run(ctx, down, false); err != nil {
+			return err
+		}
+
+		var up []Migration
+		for _, mig := range m.pending(applied) {
+			if mig.Version <= version {
+				up = append(up, mig)
+			}
+		}
+		return m.run(ctx, up, true)
+	})
+}
+
+// Force records exactly the migrations up to version as applied without running
+// any SQL, storing the checksums of the current files. It repairs state after
+// a migration was fixed by hand or intentionally edited.
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	if version != 0 && !m.known(version) {
+		return fmt.Errorf("migrate: %w %d", ErrUnknownVersion, version)
+	}
+	return m.lockedUnverified(ctx, func(applied map[uint64]Record) error {
+		var records []Record
+		for _, mig := range m.migrations {
+			if mig.Version > version {
+				break
+			}
+			rec, ok := applied[mig.Version]
+			if !ok {
+				rec = Record{Version: mig.Version, Name: mig.Name, AppliedAt: time.Now()}
+			}
+			rec.Checksum = mig.Checksum()
+			records = append(records, rec)
+		}
+		if m.dryRun {
+			fmt.Fprintf(m.out, "-- [dry-run] force version %d: would record %d migrations as applied\n", version, len(records))
+			return nil
+		}
+		if err := m.driver.SetApplied(ctx, records); err != nil {
+			return err
+		}
+		fmt.Fprintf(m.out, "forced version %d\n", version)
+		return nil
+	})
+}
+
+// Status lists every known migration and whether it is applied
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	records, err := m.driver.Applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	applied := index(records)
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		rec, ok := applied[mig.Version]
+		statuses = append(statuses, Status{
+			Migration: mig,
+			Applied:   ok,
+			AppliedAt: rec.AppliedAt,
+			Modified:  ok && rec.Checksum != "" && rec.Checksum != mig.Checksum(),
+		})
+	}
+	return statuses, nil
+}
+
+// Verify compares every applied migration with its file
+func (m *Migrator) Verify(ctx context.Context) error {
+	records, err := m.driver.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	return m.verify(records)
+}
+
+func (m *Migrator) verify(records []Record) error {
+	files := make(map[uint64]Migration, len(m.migrations))
+	for _, mig := range m.migrations {
+		files[mig.Version] = mig
+	}
+
+	var drifts []Drift
+	for _, rec := range records {
+		mig, ok := files[rec.Version]
+		switch {
+		case !ok:
+			drifts = append(drifts, Drift{Version: rec.Version, Name: rec.Name, Applied: rec.Checksum, Missing: true})
+		case rec.Checksum != "" && rec.Checksum != mig.Checksum():
+			drifts = append(drifts, Drift{Version: rec.Version, Name: rec.Name, Applied: rec.Checksum, Current: mig.Checksum()})
+		}
+	}
+	if len(drifts) > 0 {
+		return &DriftError{Drifts: drifts}
+	}
+	return nil
+}
+
+// Version returns the highest applied migration version, or 0
+func (m *Migrator) Version(ctx context.Context) (uint64, error) {
+	records, err := m.driver.Applied(ctx)
+	if err != nil || len(records) == 0 {
+		return 0, err
+	}
+	return records[len(records)-1].Version, nil
+}
+
+// Latest returns the highest known migration version, or 0
+func (m *Migrator) Latest() uint64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// WriteStatus prints statuses as a table of version, name, state and time
+// applied
+func WriteStatus(w io.Writer, statuses []Status) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, s := range statuses {
+		state, at := "pending", ""
+		if s.Applied {
+			state, at = "applied", s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		if s.Modified {
+			state = "modified"
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\t%s\n", s.Version, s.Name, state, at)
+	}
+	return tw.Flush()
+}
+
+// SchemaCheck returns a readiness check that fails while any of migrations
+// is pending or an applied one no longer matches its file. applied reads the
+// recorded state, e.g. ReadApplied over a connection pool.
+func SchemaCheck(migrations []Migration, applied func(ctx context.Context) ([]Record, error)) func(ctx context.Context) error {
+	m := New(nil, migrations, io.Discard)
+	return func(ctx context.Context) error {
+		records, err := applied(ctx)
+		if err != nil {
+			return err
+		}
+		if err := m.verify(records); err != nil {
+			return err
+		}
+		var current uint64
+		if len(records) > 0 {
+			current = records[len(records)-1].Version
+		}
+		if latest := m.Latest(); current < latest {
+			return fmt.Errorf("schema at version %d, want %d; run migrate up", current, latest)
+		}
+		return nil
+	}
+}
+
+// locked runs fn while holding the migration lock, refusing to proceed when
+// applied migrations drifted from their files
+func (m *Migrator) locked(ctx context.Context, fn func(applied map[uint64]Record) error) error {
+	return m.lockedUnverified(ctx, func(applied map[uint64]Record) error {
+		records := make([]Record, 0, len(applied))
+		for _, rec := range applied {
+			records = append(records, rec)
+		}
+		sort.Slice(records, func(i, j int) bool { return records[i].Version < records[j].Version })
+		if err := m.verify(records); err != nil {
+			return err
+		}
+		return fn(applied)
+	})
+}
+
+// lockedUnverified runs fn while holding the migration lock. A dry run only
+// reads the applied migrations, so it neither locks nor creates the table.
+func (m *Migrator) lockedUnverified(ctx context.Context, fn func(applied map[uint64]Record) error) (err error) {
+	if m.dryRun {
+		records, err := m.driver.Applied(ctx)
+		if err != nil {
+			return err
+		}
+		return fn(index(records))
+	}
+
+	if err := m.driver.Lock(ctx); err != nil {
+		return fmt.Errorf("migrate: acquire lock: %w", err)
+	}
+	defer func() {
+		if unlockErr := m.driver.Unlock(ctx); err == nil && unlockErr != nil {
+			err = fmt.Errorf("migrate: release lock: %w", unlockErr)
+		}
+	}()
+
+	if err := m.driver.Init(ctx); err != nil {
+		return err
+	}
+	records, err := m.driver.Applied(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(index(records))
+}
+
+// run applies migrations in the given order, stopping at the first failure
+func (m *Migrator) run(ctx context.Context, migrations []Migration, up bool) error {
+	direction := "up"
+	if !up {
+		direction = "down"
+	}
+
+	for _, mig := range migrations {
+		if !up && mig.Down == "" {
+			return fmt.Errorf("migrate: %w: %d_%s", ErrNoDownScript, mig.Version, mig.Name)
+		}
+		if m.dryRun {
+			script := mig.Up
+			if !up {
+				script = mig.Down
+			}
+			fmt.Fprintf(m.out, "-- [dry-run] %s %06d_%s (checksum %.12s)\n%s\n", direction, mig.Version, mig.Name, mig.Checksum(), strings.TrimRight(script, "\n"))
+			continue
+		}
+		start := time.Now()
+		if err := m.driver.Apply(ctx, mig, up); err != nil {
+			return fmt.Errorf("migrate: %d_%s %s: %w", mig.Version, mig.Name, direction, err)
+		}
+		fmt.Fprintf(m.out, "%s %06d_%s (%s)\n", direction, mig.Version, mig.Name, time.Since(start).Round(time.Millisecond))
+	}
+	return nil
+}
+
+// pending returns unapplied migrations in ascending order
+func (m *Migrator) pending(applied map[uint64]Record) []Migration {
+	var out []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			out = append(out, mig)
+		}
+	}
+	return out
+}
+
+// applied returns applied migrations in descending order
+func (m *Migrator) applied(applied map[uint64]Record) []Migration {
+	var out []Migration
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			out = append(out, m.migrations[i])
+		}
+	}
+	return out
+}
+
+func (m *Migrator) known(version uint64) bool {
+	for _, mig := range m.migrations {
+		if mig.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func index(records []Record) map[uint64]Record {
+	applied := make(map[uint64]Record, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied
+}
@@ -0,0 +1,424 @@
+package migrate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+// fakeDriver records applied migrations in memory
+type fakeDriver struct {
+	applied map[uint64]Record
+	ran     []string
+	failOn  uint64
+	locks   int
+	// writes counts calls that take the lock or change the database
+	writes int
+}
+
+func newFakeDriver() *fakeDriver {
+	return &fakeDriver{applied: make(map[uint64]Record)}
+}
+
+func (d *fakeDriver) Lock(context.Context) error   { d.locks++; d.writes++; return nil }
+func (d *fakeDriver) Unlock(context.Context) error { d.locks--; return nil }
+func (d *fakeDriver) Init(context.Context) error   { d.writes++; return nil }
+
+func (d *fakeDriver) Applied(context.Context) ([]Record, error) {
+	var out []Record
+	for _, r := range d.applied {
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func (d *fakeDriver) Apply(_ context.Context, m Migration, up bool) error {
+	if m.Version == d.failOn {
+		return errors.New("syntax error")
+	}
+	if up {
+		d.ran = append(d.ran, m.Up)
+		d.applied[m.Version] = Record{Version: m.Version, Name: m.Name, Checksum: m.Checksum(), AppliedAt: time.Now()}
+	} else {
+		d.ran = append(d.ran, m.Down)
+		delete(d.applied, m.Version)
+	}
+	return nil
+}
+
+func (d *fakeDriver) SetApplied(_ context.Context, records []Record) error {
+	d.writes++
+	d.applied = make(map[uint64]Record)
+	for _, r := range records {
+		d.applied[r.Version] = r
+	}
+	return nil
+}
+
+func (d *fakeDriver) versions() []uint64 {
+	var out []uint64
+	for v := range d.applied {
+		out = append(out, v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i] < out[j] })
+	return out
+}
+
+var testFS = fstest.MapFS{
+	"000001_create_users.up.sql":      {Data: []byte("up1")},
+	"000001_create_users.down.sql":    {Data: []byte("down1")},
+	"000002_create_messages.up.sql":   {Data: []byte("up2")},
+	"000002_create_messages.down.sql": {Data: []byte("down2")},
+	"000003_add_index.up.sql":         {Data: []byte("up3")},
+	"000003_add_index.down.sql":       {Data: []byte("down3")},
+	"README.md":                       {Data: []byte("ignored")},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, *fakeDriver) {
+	t.Helper()
+	migrations, err := Load(testFS)
+	if err != nil {
+		t.Fatalf("Expected migrations to load, got %v", err)
+	}
+	driver := newFakeDriver()
+	return New(driver, migrations, nil), driver
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 3 {
+		t.Fatalf("Expected 3 migrations, got %d", len(migrations))
+	}
+	if migrations[1].Version != 2 || migrations[1].Name != "create_messages" || migrations[1].Up != "up2" || migrations[1].Down != "down2" {
+		t.Errorf("Unexpected migration %+v", migrations[1])
+	}
+
+	_, err = Load(fstest.MapFS{"000001_a.down.sql": {Data: []byte("x")}})
+	if !errors.Is(err, ErrMissingUp) {
+		t.Errorf("Expected ErrMissingUp, got %v", err)
+	}
+
+	_, err = Load(fstest.MapFS{
+		"000001_a.up.sql": {Data: []byte("x")},
+		"000001_b.up.sql": {Data: []byte("y")},
+	})
+	if !errors.Is(err, ErrDuplicateVersion) {
+		t.Errorf("Expected ErrDuplicateVersion, got %v", err)
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+
+	if err := m.Up(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Errorf("Expected version 1 applied, got %v", got)
+	}
+
+	if err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := m.Version(ctx); v != 3 {
+		t.Errorf("Expected version 3, got %d", v)
+	}
+
+	if err := m.Down(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Errorf("Expected only version 1 applied, got %v", got)
+	}
+
+	want := []string{"up1", "up2", "up3", "down3", "down2"}
+	if !reflect.DeepEqual(driver.ran, want) {
+		t.Errorf("Expected scripts %v, got %v", want, driver.ran)
+	}
+	if driver.locks != 0 {
+		t.Errorf("Expected every lock to be released, got %d held", driver.locks)
+	}
+}
+
+func TestGoto(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+
+	if err := m.Goto(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("Expected versions 1,2, got %v", got)
+	}
+
+	if err := m.Goto(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := driver.versions(); len(got) != 0 {
+		t.Errorf("Expected no versions, got %v", got)
+	}
+
+	if err := m.Goto(ctx, 9); !errors.Is(err, ErrUnknownVersion) {
+		t.Errorf("Expected ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestForceAndStatus(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+
+	if err := m.Force(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(driver.ran) != 0 {
+		t.Errorf("Expected force not to run SQL, ran %v", driver.ran)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var applied []bool
+	for _, s := range statuses {
+		applied = append(applied, s.Applied)
+	}
+	if !reflect.DeepEqual(applied, []bool{true, true, false}) {
+		t.Errorf("Expected first two applied, got %v", applied)
+	}
+}
+
+func TestUpStopsOnFailure(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	driver.failOn = 2
+
+	if err := m.Up(context.Background(), 0); err == nil {
+		t.Fatal("Expected failing migration to return an error")
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1}) {
+		t.Errorf("Expected only migrations before the failure applied, got %v", got)
+	}
+	if driver.locks != 0 {
+		t.Error("Expected lock to be released after failure")
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/000004_existing.up.sql", []byte("SELECT 1;"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	up, down, err := Create(dir, "add_tasks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if up != dir+"/000005_add_tasks.up.sql" || down != dir+"/000005_add_tasks.down.sql" {
+		t.Errorf("Unexpected file names %s, %s", up, down)
+	}
+
+	if _, _, err := Create(dir, "Bad Name"); err == nil {
+		t.Error("Expected invalid name to be rejected")
+	}
+}
+
+func TestChecksumDrift(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+
+	if err := m.Up(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edit an applied migration
+	edited := fstest.MapFS{}
+	for name, file := range testFS {
+		edited[name] = file
+	}
+	edited["000001_create_users.up.sql"] = &fstest.MapFile{Data: []byte("up1 edited")}
+	migrations, err := Load(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = New(driver, migrations, nil)
+
+	err = m.Up(ctx, 0)
+	var drift *DriftError
+	if !errors.As(err, &drift) || !errors.Is(err, ErrChecksum) {
+		t.Fatalf("Expected DriftError, got %v", err)
+	}
+	if len(drift.Drifts) != 1 || drift.Drifts[0].Version != 1 {
+		t.Errorf("Expected drift on version 1, got %+v", drift.Drifts)
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1, 2}) {
+		t.Errorf("Expected nothing to run after drift, got %v", got)
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !statuses[0].Modified || statuses[1].Modified {
+		t.Errorf("Expected only version 1 marked modified, got %+v", statuses)
+	}
+
+	// Force accepts the edited file
+	if err := m.Force(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(ctx); err != nil {
+		t.Errorf("Expected no drift after force, got %v", err)
+	}
+}
+
+func TestChecksumMissingFile(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+	if err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	migrations, _ := Load(testFS)
+	m = New(driver, migrations[:2], nil)
+	var drift *DriftError
+	if err := m.Verify(ctx); !errors.As(err, &drift) || !drift.Drifts[0].Missing {
+		t.Errorf("Expected missing file drift, got %v", err)
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	migrations, err := Load(testFS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	driver := newFakeDriver()
+	var out bytes.Buffer
+	m := New(driver, migrations, &out)
+	m.SetDryRun(true)
+
+	if err := m.Up(context.Background(), 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(driver.ran) != 0 || len(driver.applied) != 0 || driver.writes != 0 {
+		t.Errorf("Expected dry run not to touch the database, ran %v with %d writes", driver.ran, driver.writes)
+	}
+
+	got := out.String()
+	first := strings.Index(got, "up 000001_create_users")
+	second := strings.Index(got, "up 000002_create_messages")
+	if first < 0 || second < first {
+		t.Errorf("Expected migrations printed in order, got:\n%s", got)
+	}
+	if !strings.Contains(got, "up1\n") || !strings.Contains(got, "up2\n") || strings.Contains(got, "up3") {
+		t.Errorf("Expected exact SQL of the first two migrations, got:\n%s", got)
+	}
+}
+
+func TestDryRunForce(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+	if err := m.Up(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	driver.writes = 0
+
+	var out bytes.Buffer
+	m.out = &out
+	m.SetDryRun(true)
+	if err := m.Force(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	if got := driver.versions(); !reflect.DeepEqual(got, []uint64{1}) || driver.writes != 0 {
+		t.Errorf("Expected dry-run force to change nothing, got versions %v with %d writes", got, driver.writes)
+	}
+	if !strings.Contains(out.String(), "would record 3 migrations") {
+		t.Errorf("Expected the forced state to be printed, got %q", out.String())
+	}
+}
+
+func TestChecksumCoversDownScript(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+	if err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := fstest.MapFS{}
+	for name, file := range testFS {
+		edited[name] = file
+	}
+	edited["000002_create_messages.down.sql"] = &fstest.MapFile{Data: []byte("down2 edited")}
+	migrations, err := Load(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var drift *DriftError
+	if err := New(driver, migrations, nil).Verify(ctx); !errors.As(err, &drift) || drift.Drifts[0].Version != 2 {
+		t.Errorf("Expected drift on version 2 after editing its down script, got %v", err)
+	}
+}
+
+func TestWriteStatus(t *testing.T) {
+	m, _ := newTestMigrator(t)
+	ctx := context.Background()
+	if err := m.Up(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := WriteStatus(&out, statuses); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "VERSION") {
+		t.Fatalf("Expected a header and 3 rows, got:\n%s", out.String())
+	}
+	if f := strings.Fields(lines[1]); f[0] != "000001" || f[2] != "applied" {
+		t.Errorf("Expected version 1 applied, got %q", lines[1])
+	}
+	if f := strings.Fields(lines[3]); f[0] != "000003" || f[2] != "pending" {
+		t.Errorf("Expected version 3 pending, got %q", lines[3])
+	}
+}
+
+func TestSchemaCheck(t *testing.T) {
+	m, driver := newTestMigrator(t)
+	ctx := context.Background()
+	check := SchemaCheck(m.migrations, driver.Applied)
+
+	if err := m.Up(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(ctx); err == nil || !strings.Contains(err.Error(), "schema at version 2, want 3") {
+		t.Errorf("Expected a pending migration to fail the check, got %v", err)
+	}
+	if err := m.Up(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(ctx); err != nil {
+		t.Errorf("Expected an up-to-date schema to pass, got %v", err)
+	}
+
+	rec := driver.applied[1]
+	rec.Checksum = "edited"
+	driver.applied[1] = rec
+	if err := check(ctx); !errors.Is(err, ErrChecksum) {
+		t.Errorf("Expected drift to fail the check, got %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Package migrate applies numbered SQL migrations to the database
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Migration is a numbered pair of up and down SQL scripts
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Checksum fingerprints the up and down scripts so later edits to either
+// can be detected
+func (m Migration) Checksum() string {
+	h := sha256.New()
+	h.Write([]byte(m.Up))
+	h.Write([]byte{0})
+	h.Write([]byte(m.Down))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Errors returned while loading migrations
+var (
+	ErrDuplicateVersion = errors.New("duplicate migration version")
+	ErrMissingUp        = errors.New("migration has no up script")
+)
+
+// fileName matches 000001_create_users.up.sql
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Load reads every migration in fsys, sorted by version.
+// Files that do not follow the NNNNNN_name.(up|down).sql pattern are ignored.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint64]*Migration)
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migrate: %s: %w", entry.Name(), err)
+		}
+		body, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migrate: %w %d (%s and %s)", ErrDuplicateVersion, version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migrate: %w: %d_%s", ErrMissingUp, m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// nameRule limits migration names to the characters allowed in file names
+var nameRule = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// Create writes empty up and down files for the next version in dir
+func Create(dir, name string) (up, down string, err error) {
+	if !nameRule.MatchString(name) {
+		return "", "", fmt.Errorf("migrate: name %q must use lowercase letters, digits and underscores", name)
+	}
+
+	migrations, err := Load(os.DirFS(dir))
+	if err != nil {
+		return "", "", err
+	}
+	next := uint64(1)
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	base := filepath.Join(dir, fmt.Sprintf("%06d_%s", next, name))
+	up, down = base+".up.sql", base+".down.sql"
+	for _, path := range []string{up, down} {
+		header := fmt.Sprintf("-- %s\n", filepath.Base(path))
+		if err := os.WriteFile(path, []byte(header), 0o644); err != nil {
+			return "", "", err
+		}
+	}
+	return up, down, nil
+}
@@ -0,0 +1,141 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// undefinedTable is the SQLSTATE of a query against a missing table
+const undefinedTable = "42P01"
+
+// lockID is the Postgres advisory lock key shared by every migrator
+const lockID = 7_300_125_025
+
+// Postgres is a Driver backed by a single Postgres connection, so the
+// session-level advisory lock and the migrations share one session
+type Postgres struct {
+	conn *pgx.Conn
+}
+
+// NewPostgres connects to the database at url
+func NewPostgres(ctx context.Context, url string) (*Postgres, error) {
+	conn, err := pgx.Connect(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	return &Postgres{conn: conn}, nil
+}
+
+// Close closes the connection
+func (p *Postgres) Close(ctx context.Context) error {
+	return p.conn.Close(ctx)
+}
+
+// Lock takes the advisory lock, waiting for concurrent runs to finish
+func (p *Postgres) Lock(ctx context.Context) error {
+	_, err := p.conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID)
+	return err
+}
+
+// Unlock releases the advisory lock
+func (p *Postgres) Unlock(ctx context.Context) error {
+	_, err := p.conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", lockID)
+	return err
+}
+
+// Init creates the schema_migrations table, adding the checksum column to
+// tables created before checksums were tracked
+func (p *Postgres) Init(ctx context.Context) error {
+	_, err := p.conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    BIGINT PRIMARY KEY,
+			name       TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		ALTER TABLE schema_migrations ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT ''`)
+	return err
+}
+
+// Applied lists the recorded migrations. It only reads, so dry runs can
+// call it before Init: a missing table means nothing is applied, and the
+// checksum is read through to_jsonb so tables from before checksums were
+// tracked work too.
+func (p *Postgres) Applied(ctx context.Context) ([]Record, error) {
+	return ReadApplied(ctx, p.conn)
+}
+
+// Querier runs queries; *pgx.Conn and *pgxpool.Pool satisfy it
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// ReadApplied lists the migrations recorded in schema_migrations ordered by
+// version, and none when the table does not exist yet. It needs no
+// migration lock, so servers can read the state over their own pool.
+func ReadApplied(ctx context.Context, q Querier) ([]Record, error) {
+	rows, err := q.Query(ctx,
+		`SELECT version, name, COALESCE(to_jsonb(s)->>'checksum', ''), applied_at
+		 FROM schema_migrations s ORDER BY version`)
+	if err == nil {
+		var records []Record
+		records, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (Record, error) {
+			var r Record
+			var version int64
+			err := row.Scan(&version, &r.Name, &r.Checksum, &r.AppliedAt)
+			r.Version = uint64(version)
+			return r, err
+		})
+		if err == nil {
+			return records, nil
+		}
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == undefinedTable {
+		return nil, nil
+	}
+	return nil, err
+}
+
+// Apply runs the migration script and updates schema_migrations in one transaction
+func (p *Postgres) Apply(ctx context.Context, m Migration, up bool) error {
+	return pgx.BeginFunc(ctx, p.conn, func(tx pgx.Tx) error {
+		if up {
+			if _, err := tx.Exec(ctx, m.Up); err != nil {
+				return err
+			}
+			_, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum) VALUES ($1, $2, $3)",
+				int64(m.Version), m.Name, m.Checksum())
+			return err
+		}
+
+		if _, err := tx.Exec(ctx, m.Down); err != nil {
+			return err
+		}
+		_, err := tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", int64(m.Version))
+		return err
+	})
+}
+
+// SetApplied replaces the contents of schema_migrations
+func (p *Postgres) SetApplied(ctx context.Context, records []Record) error {
+	return pgx.BeginFunc(ctx, p.conn, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+			return err
+		}
+		for _, r := range records {
+			appliedAt := r.AppliedAt
+			if appliedAt.IsZero() {
+				appliedAt = time.Now()
+			}
+			if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, name, checksum, applied_at) VALUES ($1, $2, $3, $4)",
+				int64(r.Version), r.Name, r.Checksum, appliedAt); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
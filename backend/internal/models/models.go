@@ -0,0 +1,101 @@
+// Package models defines the domain types shared by storage, auth and handlers
+package models
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Validation errors wrapped by FieldError
+var (
+	ErrEmptyContent = errors.New("content is required")
+	ErrEmptyTitle   = errors.New("title cannot be empty")
+)
+
+// FieldError reports an invalid request field by its JSON name
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// User is an account that can sign in
+type User struct {
+	ID           int64     `json:"id"`
+	Email        string    `json:"email"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	Disabled     bool      `json:"disabled"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// Message represents a chat message. UserID is the author, or zero when
+// the author's account was deleted.
+type Message struct {
+	ID        int64     `json:"id"`
+	UserID    int64     `json:"user_id"`
+	Username  string    `json:"username"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// CreateMessageRequest represents the request to create a new message. The
+// author and display name come from the caller's token, not the body.
+type CreateMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// Validate checks if the create message request is valid
+func (r *CreateMessageRequest) Validate() error {
+	if strings.TrimSpace(r.Content) == "" {
+		return &FieldError{Field: "content", Err: ErrEmptyContent}
+	}
+	return nil
+}
+
+// UpdateMessageRequest represents the request to update a message
+type UpdateMessageRequest struct {
+	Content string `json:"content"`
+}
+
+// Validate checks if the update message request is valid
+func (r *UpdateMessageRequest) Validate() error {
+	if strings.TrimSpace(r.Content) == "" {
+		return &FieldError{Field: "content", Err: ErrEmptyContent}
+	}
+	return nil
+}
+
+// Task represents a single task owned by a user
+type Task struct {
+	ID          int64     `json:"id"`
+	UserID      int64     `json:"user_id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Done        bool      `json:"done"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TaskRequest is the body used to create or update a task
+type TaskRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Done        bool   `json:"done"`
+}
+
+// Validate checks if the task request is valid
+func (r *TaskRequest) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return &FieldError{Field: "title", Err: ErrEmptyTitle}
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+//go:embed docs.html
+var docsHTML string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsHTML))
+
+// DocsHandler serves a self-contained documentation page rendering the
+// document found at specURL. It needs no assets from the network.
+func DocsHandler(specURL string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := docsTemplate.Execute(c.Writer, struct{ SpecURL string }{specURL}); err != nil {
+			_ = c.Error(err)
+		}
+	}
+}
@@ -0,0 +1,317 @@
+// Package openapi builds an OpenAPI 3.1 document from the routes registered
+// through it, deriving schemas from Go request and response types
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI specification version produced
+const Version = "3.1.0"
+
+// bearerScheme names the security scheme used by authenticated operations
+const bearerScheme = "bearerAuth"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                         `json:"openapi"`
+	Info       Info                           `json:"info"`
+	Servers    []Server                       `json:"servers,omitempty"`
+	Paths      map[string]map[string]*OpEntry `json:"paths"`
+	Components Components                     `json:"components"`
+
+	basePath    string
+	errorType   reflect.Type
+	errorMedia  string
+	schemaNames map[reflect.Type]string
+}
+
+// Info describes the API
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// Server is a base URL the paths are relative to
+type Server struct {
+	URL string `json:"url"`
+}
+
+// Components holds the reusable schemas and security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes how requests authenticate
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// OpEntry is a documented operation as it appears in the document
+type OpEntry struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*ParamEntry         `json:"parameters,omitempty"`
+	RequestBody *Body                 `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+// ParamEntry is a documented path or query parameter
+type ParamEntry struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// Body is a JSON request body
+type Body struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is one documented response
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Operation describes a route when it is registered
+type Operation struct {
+	ID      string
+	Summary string
+	Tags    []string
+	// Auth marks routes that need a bearer access token
+	Auth bool
+	// Params documents path, query and header parameters. Path parameters that are
+	// not listed are documented as strings.
+	Params []Param
+	// Request is a value of the JSON request body type, or nil
+	Request any
+	// Response is a value of the success body type, or nil for no body
+	Response any
+	// Status is the success status code, 200 by default
+	Status int
+	// Errors lists additional error statuses the operation returns
+	Errors []int
+}
+
+// Param documents a path, query or header parameter. Example is a value of the
+// parameter's type and determines its schema.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Example     any
+}
+
+// PathParam documents a path parameter
+func PathParam(name, description string, example any) Param {
+	return Param{Name: name, In: "path", Description: description, Example: example}
+}
+
+// QueryParam documents an optional query parameter
+func QueryParam(name, description string, example any) Param {
+	return Param{Name: name, In: "query", Description: description, Example: example}
+}
+
+// HeaderParam documents an optional request header
+func HeaderParam(name, description string, example any) Param {
+	return Param{Name: name, In: "header", Description: description, Example: example}
+}
+
+// New creates an empty document for the API served under basePath.
+// errorBody is a value of the type every error response carries.
+func New(title, version, basePath string, errorBody any) *Document {
+	return &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: title, Version: version},
+		Servers: []Server{{URL: basePath}},
+		Paths:   make(map[string]map[string]*OpEntry),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]*SecurityScheme{
+				bearerScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+		basePath:    strings.TrimSuffix(basePath, "/"),
+		errorType:   reflect.TypeOf(errorBody),
+		errorMedia:  "application/json",
+		schemaNames: make(map[reflect.Type]string),
+	}
+}
+
+// SetErrorMediaType sets the media type of error responses, which
+// defaults to application/json
+func (d *Document) SetErrorMediaType(mediaType string) {
+	d.errorMedia = mediaType
+}
+
+var (
+	ginParam  = regexp.MustCompile(`[:*]([A-Za-z0-9_]+)`)
+	pathParam = regexp.MustCompile(`\{([A-Za-z0-9_]+)\}`)
+)
+
+// Add documents an operation. path is the full gin route, e.g.
+// /api/v1/messages/:id, and is stored relative to the base path.
+func (d *Document) Add(method, path string, op Operation) {
+	path = d.specPath(path)
+
+	entry := &OpEntry{
+		OperationID: op.ID,
+		Summary:     op.Summary,
+		Tags:        op.Tags,
+		Responses:   make(map[string]*Response),
+	}
+
+	listed := make(map[string]bool)
+	for _, p := range op.Params {
+		listed[p.Name] = true
+		entry.Parameters = append(entry.Parameters, &ParamEntry{
+			Name:        p.Name,
+			In:          p.In,
+			Description: p.Description,
+			Required:    p.In == "path",
+			Schema:      d.schemaFor(reflect.TypeOf(p.Example)),
+		})
+	}
+	for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+		if !listed[m[1]] {
+			entry.Parameters = append(entry.Parameters, &ParamEntry{Name: m[1], In: "path", Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	if op.Request != nil {
+		entry.RequestBody = &Body{Required: true, Content: d.jsonContent(op.Request)}
+	}
+
+	status := op.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := &Response{Description: http.StatusText(status)}
+	if op.Response != nil {
+		success.Content = d.jsonContent(op.Response)
+	}
+	entry.Responses[strconv.Itoa(status)] = success
+
+	failures := append([]int{}, op.Errors...)
+	if op.Request != nil || len(op.Params) > 0 {
+		failures = append(failures, http.StatusBadRequest)
+	}
+	if op.Auth {
+		failures = append(failures, http.StatusUnauthorized)
+		entry.Security = []map[string][]string{{bearerScheme: {}}}
+	}
+	for _, code := range failures {
+		entry.Responses[strconv.Itoa(code)] = &Response{
+			Description: http.StatusText(code),
+			Content:     map[string]*MediaType{d.errorMedia: {Schema: d.schemaFor(d.errorType)}},
+		}
+	}
+
+	if d.Paths[path] == nil {
+		d.Paths[path] = make(map[string]*OpEntry)
+	}
+	d.Paths[path][strings.ToLower(method)] = entry
+}
+
+// Has reports whether the gin route method path is documented
+func (d *Document) Has(method, path string) bool {
+	_, ok := d.Paths[d.specPath(path)][strings.ToLower(method)]
+	return ok
+}
+
+// specPath converts a gin route into a templated path relative to the base
+func (d *Document) specPath(path string) string {
+	path = ginParam.ReplaceAllString(strings.TrimPrefix(path, d.basePath), "{$1}")
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+// Handler serves the document as JSON
+func (d *Document) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, d)
+	}
+}
+
+func (d *Document) jsonContent(v any) map[string]*MediaType {
+	return map[string]*MediaType{"application/json": {Schema: d.schemaFor(reflect.TypeOf(v))}}
+}
+
+// Router registers gin routes and documents them in one step
+type Router struct {
+	group  *gin.RouterGroup
+	doc    *Document
+	errors []int
+}
+
+// Router returns a router that registers routes on group
+func (d *Document) Router(group *gin.RouterGroup) *Router {
+	return &Router{group: group, doc: d}
+}
+
+// Group creates a sub-router with additional middleware
+func (r *Router) Group(path string, handlers ...gin.HandlerFunc) *Router {
+	return &Router{group: r.group.Group(path, handlers...), doc: r.doc, errors: r.errors}
+}
+
+// WithErrors returns a router that documents the given error statuses, such
+// as those returned by group middleware, on every route it registers
+func (r *Router) WithErrors(codes ...int) *Router {
+	return &Router{group: r.group, doc: r.doc, errors: append(append([]int{}, r.errors...), codes...)}
+}
+
+// Handle registers and documents a route
+func (r *Router) Handle(method, path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.group.Handle(method, path, handlers...)
+	op.Errors = append(append([]int{}, op.Errors...), r.errors...)
+	r.doc.Add(method, joinPath(r.group.BasePath(), path), op)
+}
+
+// GET registers and documents a GET route
+func (r *Router) GET(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodGet, path, op, handlers...)
+}
+
+// POST registers and documents a POST route
+func (r *Router) POST(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPost, path, op, handlers...)
+}
+
+// PUT registers and documents a PUT route
+func (r *Router) PUT(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodPut, path, op, handlers...)
+}
+
+// DELETE registers and documents a DELETE route
+func (r *Router) DELETE(path string, op Operation, handlers ...gin.HandlerFunc) {
+	r.Handle(http.MethodDelete, path, op, handlers...)
+}
+
+func joinPath(base, path string) string {
+	if path == "" {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/" + strings.TrimPrefix(path, "/")
+}
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type testItem struct {
+	ID       int64      `json:"id"`
+	Name     string     `json:"name"`
+	Note     string     `json:"note,omitempty"`
+	Secret   string     `json:"-"`
+	Parent   *testItem  `json:"parent"`
+	Tags     []string   `json:"tags"`
+	DoneAt   *time.Time `json:"done_at"`
+	internal bool
+}
+
+type testError struct {
+	Error string `json:"error"`
+}
+
+func TestStructSchema(t *testing.T) {
+	doc := New("test", "1", "/api", testError{})
+	ref := doc.schemaFor(reflect.TypeOf(testItem{}))
+	if ref.Ref != "#/components/schemas/testItem" {
+		t.Fatalf("Expected a component reference, got %+v", ref)
+	}
+
+	s := doc.Components.Schemas["testItem"]
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []string{"done_at", "id", "name", "note", "parent", "tags"}; !slices.Equal(names, want) {
+		t.Errorf("Properties = %v, want %v", names, want)
+	}
+	if want := []string{"id", "name", "tags"}; !slices.Equal(s.Required, want) {
+		t.Errorf("Required = %v, want %v", s.Required, want)
+	}
+	if s.Properties["parent"].Ref == "" {
+		t.Error("Expected recursive field to reference the component")
+	}
+	if got := s.Properties["done_at"]; got.Format != "date-time" || !reflect.DeepEqual(got.Type, []any{"string", "null"}) {
+		t.Errorf("Expected nullable date-time, got %+v", got)
+	}
+}
+
+func TestRouterDocumentsRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doc := New("test", "1", "/api", testError{})
+	router := gin.New()
+	noop := func(*gin.Context) {}
+
+	r := doc.Router(router.Group("/api")).WithErrors(http.StatusTooManyRequests)
+	r.GET("/items/:id", Operation{Params: []Param{PathParam("id", "item ID", int64(0))}, Response: testItem{}}, noop)
+	r.Group("/admin").POST("", Operation{Auth: true, Request: testItem{}, Status: http.StatusCreated}, noop)
+
+	if !doc.Has(http.MethodGet, "/api/items/:id") || !doc.Has(http.MethodPost, "/api/admin") {
+		t.Fatalf("Expected both routes documented, got %v", doc.Paths)
+	}
+	if doc.Has(http.MethodDelete, "/api/items/:id") {
+		t.Error("Expected DELETE to be undocumented")
+	}
+
+	get := doc.Paths["/items/{id}"]["get"]
+	if len(get.Parameters) != 1 || get.Parameters[0].Schema.Format != "int64" || !get.Parameters[0].Required {
+		t.Errorf("Unexpected parameters %+v", get.Parameters)
+	}
+	for _, code := range []string{"200", "400", "429"} {
+		if get.Responses[code] == nil {
+			t.Errorf("Expected %s response on GET", code)
+		}
+	}
+
+	post := doc.Paths["/admin"]["post"]
+	if post.Security == nil || post.Responses["401"] == nil || post.Responses["201"] == nil {
+		t.Errorf("Expected authenticated 201 operation, got %+v", post)
+	}
+}
@@ -0,0 +1,186 @@
+// Package problem renders errors as RFC 7807 application/problem+json
+// responses and maps domain errors to HTTP status codes
+package problem
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/idempotency"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// ContentType is the media type of problem responses
+const ContentType = "application/problem+json"
+
+// Problem is an RFC 7807 problem details object
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field  string `json:"field"`
+	Detail string `json:"detail"`
+}
+
+// New returns a problem with the standard title of status
+func New(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// Validation returns a 400 problem listing invalid fields
+func Validation(fields ...FieldError) *Problem {
+	p := New(http.StatusBadRequest, "the request has invalid fields")
+	p.Errors = fields
+	return p
+}
+
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return p.Detail
+	}
+	return p.Title
+}
+
+// statuses maps domain errors to status codes. More specific errors must
+// come before the errors they wrap.
+var statuses = []struct {
+	err    error
+	status int
+}{
+	{repository.ErrNotFound, http.StatusNotFound},
+	{repository.ErrConflict, http.StatusConflict},
+	{repository.ErrInvalidReference, http.StatusUnprocessableEntity},
+	{auth.ErrInvalidCredentials, http.StatusUnauthorized},
+	{auth.ErrInvalidToken, http.StatusUnauthorized},
+	{auth.ErrTokenExpired, http.StatusUnauthorized},
+	{auth.ErrTokenRevoked, http.StatusUnauthorized},
+	{auth.ErrUserDisabled, http.StatusForbidden},
+	{idempotency.ErrInFlight, http.StatusConflict},
+	{idempotency.ErrKeyReused, http.StatusUnprocessableEntity},
+}
+
+// From converts err into a problem. Problems are returned as they are,
+// known domain and validation errors get their status code and message,
+// and anything else becomes a 500 that does not reveal the error.
+func From(err error) *Problem {
+	var p *Problem
+	if errors.As(err, &p) {
+		return p
+	}
+
+	if fields := fieldErrors(err); len(fields) > 0 {
+		return Validation(fields...)
+	}
+
+	for _, s := range statuses {
+		if errors.Is(err, s.err) {
+			return New(s.status, err.Error())
+		}
+	}
+	return New(http.StatusInternalServerError, "")
+}
+
+// InvalidBody converts an error from binding the request body into a 400
+// problem, listing the invalid fields when binding validation failed
+func InvalidBody(err error) *Problem {
+	if fields := fieldErrors(err); len(fields) > 0 {
+		return Validation(fields...)
+	}
+	return New(http.StatusBadRequest, "the request body is not valid JSON for this operation")
+}
+
+// Write renders err as a problem response and aborts the handler chain.
+// Server errors are attached to the gin context so the request log
+// records the cause that the response hides.
+func Write(c *gin.Context, err error) {
+	p := *From(err)
+	if p.Status >= http.StatusInternalServerError {
+		_ = c.Error(err)
+	}
+	if p.Instance == "" {
+		p.Instance = c.Request.URL.Path
+	}
+	p.RequestID = logging.RequestID(c.Request.Context())
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(p.Status, &p)
+}
+
+// Recovery turns panics into 500 problem responses
+func Recovery() gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		Write(c, fmt.Errorf("panic: %v", recovered))
+	})
+}
+
+// NotFound answers requests that match no route
+func NotFound(c *gin.Context) {
+	Write(c, New(http.StatusNotFound, "no route matches "+c.Request.Method+" "+c.Request.URL.Path))
+}
+
+// init makes gin's binding validator report fields by their JSON names,
+// so binding errors name the keys the client actually sent
+func init() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonName)
+	}
+}
+
+// jsonName returns the name encoding/json uses for a struct field, or ""
+// to keep the Go name
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+// fieldErrors collects the invalid fields reported by models validation and
+// gin binding anywhere in err's tree
+func fieldErrors(err error) []FieldError {
+	var fields []FieldError
+	switch e := err.(type) {
+	case *models.FieldError:
+		fields = append(fields, FieldError{Field: e.Field, Detail: e.Error()})
+	case validator.ValidationErrors:
+		for _, f := range e {
+			fields = append(fields, FieldError{Field: f.Field(), Detail: validationDetail(f)})
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			fields = append(fields, fieldErrors(inner)...)
+		}
+	case interface{ Unwrap() error }:
+		fields = fieldErrors(e.Unwrap())
+	}
+	return fields
+}
+
+func validationDetail(f validator.FieldError) string {
+	switch f.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be an email address"
+	}
+	return "failed the " + f.Tag() + " rule"
+}
@@ -0,0 +1,149 @@
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/auth"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/logging"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+func TestFromMapsDomainErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+	}{
+		{"message not found", repository.ErrMessageNotFound, http.StatusNotFound, "message not found"},
+		{"wrapped task not found", fmt.Errorf("load: %w", repository.ErrTaskNotFound), http.StatusNotFound, "load: task not found"},
+		{"email taken", repository.ErrEmailTaken, http.StatusConflict, "email already exists"},
+		{"bad credentials", auth.ErrInvalidCredentials, http.StatusUnauthorized, auth.ErrInvalidCredentials.Error()},
+		{"disabled user", auth.ErrUserDisabled, http.StatusForbidden, auth.ErrUserDisabled.Error()},
+		{"problem", New(http.StatusTeapot, "short and stout"), http.StatusTeapot, "short and stout"},
+		{"unknown", errors.New("connection reset"), http.StatusInternalServerError, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := From(tt.err)
+			if p.Status != tt.status || p.Detail != tt.detail {
+				t.Fatalf("Expected %d %q, got %d %q", tt.status, tt.detail, p.Status, p.Detail)
+			}
+			if p.Title != http.StatusText(tt.status) {
+				t.Fatalf("Expected title %q, got %q", http.StatusText(tt.status), p.Title)
+			}
+		})
+	}
+}
+
+func TestFromCollectsFieldErrors(t *testing.T) {
+	err := errors.Join(
+		&models.FieldError{Field: "title", Err: models.ErrEmptyTitle},
+		&models.FieldError{Field: "content", Err: models.ErrEmptyContent},
+	)
+
+	p := From(err)
+	if p.Status != http.StatusBadRequest {
+		t.Fatalf("Expected status 400, got %d", p.Status)
+	}
+	if len(p.Errors) != 2 || p.Errors[0].Field != "title" || p.Errors[1].Field != "content" {
+		t.Fatalf("Expected title and content field errors, got %+v", p.Errors)
+	}
+}
+
+func TestInvalidBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var req struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+		UserID       int64  `json:"user_id,omitempty" binding:"required"`
+		Email        string `binding:"required"`
+	}
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`))
+	p := InvalidBody(c.ShouldBindJSON(&req))
+	want := []FieldError{
+		{Field: "refresh_token", Detail: "is required"},
+		{Field: "user_id", Detail: "is required"},
+		{Field: "Email", Detail: "is required"},
+	}
+	if !slices.Equal(p.Errors, want) {
+		t.Fatalf("Expected fields named by their JSON keys %+v, got %+v", want, p.Errors)
+	}
+
+	c.Request = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{`))
+	if p := InvalidBody(c.ShouldBindJSON(&req)); p.Status != http.StatusBadRequest || len(p.Errors) != 0 {
+		t.Fatalf("Expected a plain 400 for malformed JSON, got %+v", p)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), "req-1"))
+	})
+	router.Use(Recovery())
+	router.NoRoute(NotFound)
+	router.GET("/messages/:id", func(c *gin.Context) {
+		Write(c, repository.ErrMessageNotFound)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		Write(c, errors.New("secret connection string"))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		detail string
+	}{
+		{"/messages/7", http.StatusNotFound, "message not found"},
+		{"/fail", http.StatusInternalServerError, ""},
+		{"/panic", http.StatusInternalServerError, ""},
+		{"/missing", http.StatusNotFound, "no route matches GET /missing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			if w.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, w.Code)
+			}
+			if ct := w.Header().Get("Content-Type"); ct != ContentType {
+				t.Fatalf("Expected content type %s, got %q", ContentType, ct)
+			}
+
+			var p Problem
+			if err := json.Unmarshal(w.Body.Bytes(), &p); err != nil {
+				t.Fatalf("Failed to decode problem: %v", err)
+			}
+			want := Problem{
+				Type:      "about:blank",
+				Title:     http.StatusText(tt.status),
+				Status:    tt.status,
+				Detail:    tt.detail,
+				Instance:  tt.path,
+				RequestID: "req-1",
+			}
+			if p.Type != want.Type || p.Title != want.Title || p.Status != want.Status ||
+				p.Detail != want.Detail || p.Instance != want.Instance || p.RequestID != want.RequestID {
+				t.Fatalf("Expected %+v, got %+v", want, p)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// Package ratelimit implements token-bucket rate limiting with pluggable state stores
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/metrics"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second holding at most Burst tokens.
+// A zero Rate disables limiting.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit never rejects requests
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking one token
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. The in-memory store serves a single instance;
+// a shared store such as Redis can implement the same interface for clusters.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Policy is a named limit shared by a route group. Its limit can be changed
+// at runtime, for example on configuration reload.
+type Policy struct {
+	name  string
+	limit atomic.Pointer[Limit]
+}
+
+// NewPolicy creates a named policy
+func NewPolicy(name string, limit Limit) *Policy {
+	p := &Policy{name: name}
+	p.SetLimit(limit)
+	return p
+}
+
+// Name returns the policy name used to namespace bucket keys
+func (p *Policy) Name() string {
+	return p.name
+}
+
+// Limit returns the current limit
+func (p *Policy) Limit() Limit {
+	return *p.limit.Load()
+}
+
+// SetLimit replaces the limit
+func (p *Policy) SetLimit(limit Limit) {
+	p.limit.Store(&limit)
+}
+
+// sweepInterval controls how often idle buckets are evicted
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	full   time.Time
+}
+
+// MemoryStore keeps token buckets in process memory
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	now       func() time.Time
+	lastSweep time.Time
+}
+
+// NewMemoryStore creates an in-memory store
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithClock(time.Now)
+}
+
+// NewMemoryStoreWithClock creates an in-memory store using now as its clock
+func NewMemoryStoreWithClock(now func() time.Time) *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket), now: now, lastSweep: now()}
+}
+
+// Take removes one token from the bucket for key
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	burst := float64(limit.Burst)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*limit.Rate)
+	b.last = now
+
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = seconds((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = seconds((burst - b.tokens) / limit.Rate)
+	b.full = now.Add(res.Reset)
+	return res, nil
+}
+
+// sweep drops buckets that have refilled completely and so carry no state
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if !b.full.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// RegisterMetrics exports the number of tracked buckets
+func (s *MemoryStore) RegisterMetrics(reg prometheus.Registerer) error {
+	return reg.Register(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: metrics.Namespace,
+		Subsystem: "ratelimit",
+		Name:      "buckets",
+		Help:      "Token buckets currently tracked by the in-memory rate limiter.",
+	}, func() float64 { return float64(s.Len()) }))
+}
+
+// Len returns the number of tracked buckets
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
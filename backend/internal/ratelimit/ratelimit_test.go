@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeClock struct{ t time.Time }
+
+func (c *fakeClock) Now() time.Time          { return c.t }
+func (c *fakeClock) Advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	store := NewMemoryStoreWithClock(clock.Now)
+	limit := Limit{Rate: 1, Burst: 3}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		res, err := store.Take(ctx, "client", limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+		if res.Remaining != 2-i {
+			t.Errorf("Expected remaining %d, got %d", 2-i, res.Remaining)
+		}
+	}
+
+	res, _ := store.Take(ctx, "client", limit)
+	if res.Allowed {
+		t.Fatal("Expected request beyond burst to be rejected")
+	}
+	if res.RetryAfter != time.Second {
+		t.Errorf("Expected retry after 1s, got %v", res.RetryAfter)
+	}
+	if res.Reset != 3*time.Second {
+		t.Errorf("Expected reset in 3s, got %v", res.Reset)
+	}
+
+	// Other clients have their own bucket
+	if res, _ := store.Take(ctx, "other", limit); !res.Allowed {
+		t.Error("Expected a different key to be allowed")
+	}
+
+	clock.Advance(time.Second)
+	if res, _ := store.Take(ctx, "client", limit); !res.Allowed {
+		t.Error("Expected a token to be refilled after 1s")
+	}
+}
+
+func TestMemoryStoreUnlimited(t *testing.T) {
+	store := NewMemoryStore()
+	for i := 0; i < 100; i++ {
+		if res, _ := store.Take(context.Background(), "client", Limit{}); !res.Allowed {
+			t.Fatal("Expected zero limit to allow every request")
+		}
+	}
+	if store.Len() != 0 {
+		t.Errorf("Expected unlimited requests not to create buckets, got %d", store.Len())
+	}
+}
+
+func TestMemoryStoreEvictsIdleBuckets(t *testing.T) {
+	clock := &fakeClock{t: time.Unix(1000, 0)}
+	store := NewMemoryStoreWithClock(clock.Now)
+	limit := Limit{Rate: 10, Burst: 10}
+
+	store.Take(context.Background(), "a", limit)
+	store.Take(context.Background(), "b", limit)
+
+	clock.Advance(2 * sweepInterval)
+	store.Take(context.Background(), "c", limit)
+
+	if store.Len() != 1 {
+		t.Errorf("Expected idle buckets to be evicted, got %d buckets", store.Len())
+	}
+}
+
+func TestPolicySetLimit(t *testing.T) {
+	p := NewPolicy("api", Limit{Rate: 1, Burst: 1})
+	p.SetLimit(Limit{Rate: 5, Burst: 10})
+	if got := p.Limit(); got.Rate != 5 || got.Burst != 10 {
+		t.Errorf("Expected updated limit, got %+v", got)
+	}
+}
@@ -0,0 +1,41 @@
+// Package memory implements the repository interfaces in process memory.
+// It is used for development and by handler tests that run without Postgres.
+package memory
+
+import (
+	"sort"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// New returns empty in-memory repositories that enforce the same references
+// as the Postgres schema: tasks need an existing user and go away with them,
+// and messages outlive their author
+func New() *repository.Repositories {
+	users := NewUserRepository()
+	messages := NewMessageRepository()
+	tasks := NewTaskRepository()
+	users.messages = messages
+	users.tasks = tasks
+	messages.users = users
+	tasks.users = users
+	return &repository.Repositories{
+		Users:    users,
+		Messages: messages,
+		Tasks:    tasks,
+	}
+}
+
+// page returns the IDs selected by opts after sorting them ascending
+func page(ids []int64, opts repository.ListOptions) []int64 {
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if opts.Offset >= len(ids) {
+		return nil
+	}
+	ids = ids[max(opts.Offset, 0):]
+	if opts.Limit > 0 && opts.Limit < len(ids) {
+		ids = ids[:opts.Limit]
+	}
+	return ids
+}
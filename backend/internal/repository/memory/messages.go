@@ -0,0 +1,117 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// MessageRepository is an in-memory repository.MessageRepository
+type MessageRepository struct {
+	mu       sync.RWMutex
+	messages map[int64]models.Message
+	nextID   int64
+
+	// users, when set, must contain the author of every created message
+	users *UserRepository
+}
+
+// NewMessageRepository creates an empty message repository
+func NewMessageRepository() *MessageRepository {
+	return &MessageRepository{messages: make(map[int64]models.Message), nextID: 1}
+}
+
+// Create stores msg, filling in its ID and timestamp
+func (r *MessageRepository) Create(ctx context.Context, msg *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users != nil && msg.UserID != 0 && !r.users.exists(msg.UserID) {
+		return repository.ErrUnknownUser
+	}
+	msg.ID = r.nextID
+	msg.Timestamp = time.Now().UTC().Truncate(time.Microsecond)
+	r.messages[msg.ID] = *msg
+	r.nextID++
+	return nil
+}
+
+// GetByID returns the message with the given ID
+func (r *MessageRepository) GetByID(ctx context.Context, id int64) (*models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, ok := r.messages[id]
+	if !ok {
+		return nil, repository.ErrMessageNotFound
+	}
+	return &m, nil
+}
+
+// List returns messages ordered by ID
+func (r *MessageRepository) List(ctx context.Context, opts repository.ListOptions) ([]*models.Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.messages))
+	for id := range r.messages {
+		ids = append(ids, id)
+	}
+
+	messages := make([]*models.Message, 0, len(ids))
+	for _, id := range page(ids, opts) {
+		m := r.messages[id]
+		messages = append(messages, &m)
+	}
+	return messages, nil
+}
+
+// Update replaces the stored content
+func (r *MessageRepository) Update(ctx context.Context, msg *models.Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.messages[msg.ID]
+	if !ok {
+		return repository.ErrMessageNotFound
+	}
+	stored.Content = msg.Content
+	r.messages[msg.ID] = stored
+	*msg = stored
+	return nil
+}
+
+// Delete removes the message with the given ID
+func (r *MessageRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.messages[id]; !ok {
+		return repository.ErrMessageNotFound
+	}
+	delete(r.messages, id)
+	return nil
+}
+
+// Count returns the number of stored messages
+func (r *MessageRepository) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.messages), nil
+}
+
+// clearUser forgets userID as the author of their messages
+func (r *MessageRepository) clearUser(userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, m := range r.messages {
+		if m.UserID == userID {
+			m.UserID = 0
+			r.messages[id] = m
+		}
+	}
+}
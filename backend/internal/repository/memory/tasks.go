@@ -0,0 +1,120 @@
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// TaskRepository is an in-memory repository.TaskRepository
+type TaskRepository struct {
+	mu     sync.RWMutex
+	tasks  map[int64]models.Task
+	nextID int64
+
+	// users, when set, must contain the owner of every created task
+	users *UserRepository
+}
+
+// NewTaskRepository creates an empty task repository
+func NewTaskRepository() *TaskRepository {
+	return &TaskRepository{tasks: make(map[int64]models.Task), nextID: 1}
+}
+
+// Create stores task, filling in its ID and timestamps
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.users != nil && !r.users.exists(task.UserID) {
+		return repository.ErrUnknownUser
+	}
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	task.ID = r.nextID
+	task.CreatedAt = now
+	task.UpdatedAt = now
+	r.tasks[task.ID] = *task
+	r.nextID++
+	return nil
+}
+
+// GetByID returns the task with the given ID
+func (r *TaskRepository) GetByID(ctx context.Context, id int64) (*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.tasks[id]
+	if !ok {
+		return nil, repository.ErrTaskNotFound
+	}
+	return &t, nil
+}
+
+// List returns the tasks matching filter ordered by ID
+func (r *TaskRepository) List(ctx context.Context, filter repository.TaskFilter, opts repository.ListOptions) ([]*models.Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ids []int64
+	for id, t := range r.tasks {
+		if filter.UserID != 0 && t.UserID != filter.UserID {
+			continue
+		}
+		if filter.Done != nil && t.Done != *filter.Done {
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	tasks := make([]*models.Task, 0, len(ids))
+	for _, id := range page(ids, opts) {
+		t := r.tasks[id]
+		tasks = append(tasks, &t)
+	}
+	return tasks, nil
+}
+
+// Update replaces the stored title, description and done flag
+func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.tasks[task.ID]
+	if !ok {
+		return repository.ErrTaskNotFound
+	}
+	stored.Title = task.Title
+	stored.Description = task.Description
+	stored.Done = task.Done
+	stored.UpdatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.tasks[task.ID] = stored
+	*task = stored
+	return nil
+}
+
+// Delete removes the task with the given ID
+func (r *TaskRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.tasks[id]; !ok {
+		return repository.ErrTaskNotFound
+	}
+	delete(r.tasks, id)
+	return nil
+}
+
+// deleteByUser removes every task owned by userID
+func (r *TaskRepository) deleteByUser(userID int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, t := range r.tasks {
+		if t.UserID == userID {
+			delete(r.tasks, id)
+		}
+	}
+}
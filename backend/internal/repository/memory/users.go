@@ -0,0 +1,154 @@
+package memory
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// UserRepository is an in-memory repository.UserRepository
+type UserRepository struct {
+	mu     sync.RWMutex
+	users  map[int64]models.User
+	nextID int64
+
+	// messages and tasks, when set, follow a user's deletion: messages lose
+	// their author and tasks are deleted
+	messages *MessageRepository
+	tasks    *TaskRepository
+}
+
+// NewUserRepository creates an empty user repository
+func NewUserRepository() *UserRepository {
+	return &UserRepository{users: make(map[int64]models.User), nextID: 1}
+}
+
+// Create stores user, filling in its ID and creation time
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	user.Email = strings.ToLower(user.Email)
+	if r.emailTaken(user.Email, 0) {
+		return repository.ErrEmailTaken
+	}
+	if user.Role == "" {
+		user.Role = "user"
+	}
+
+	user.ID = r.nextID
+	user.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+	r.users[user.ID] = *user
+	r.nextID++
+	return nil
+}
+
+// GetByID returns the user with the given ID
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrUserNotFound
+	}
+	return &u, nil
+}
+
+// GetByEmail returns the user with the given email, ignoring case
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	email = strings.ToLower(email)
+	for _, u := range r.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, repository.ErrUserNotFound
+}
+
+// List returns users ordered by ID
+func (r *UserRepository) List(ctx context.Context, opts repository.ListOptions) ([]*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	ids := make([]int64, 0, len(r.users))
+	for id := range r.users {
+		ids = append(ids, id)
+	}
+
+	users := make([]*models.User, 0, len(ids))
+	for _, id := range page(ids, opts) {
+		u := r.users[id]
+		users = append(users, &u)
+	}
+	return users, nil
+}
+
+// Update replaces the stored email, password hash, role and disabled flag
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stored, ok := r.users[user.ID]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	user.Email = strings.ToLower(user.Email)
+	if r.emailTaken(user.Email, user.ID) {
+		return repository.ErrEmailTaken
+	}
+
+	stored.Email = user.Email
+	stored.PasswordHash = user.PasswordHash
+	stored.Role = user.Role
+	stored.Disabled = user.Disabled
+	r.users[user.ID] = stored
+	*user = stored
+	return nil
+}
+
+// Delete removes the user with the given ID together with their tasks
+func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+	r.mu.Lock()
+	if _, ok := r.users[id]; !ok {
+		r.mu.Unlock()
+		return repository.ErrUserNotFound
+	}
+	delete(r.users, id)
+	r.mu.Unlock()
+
+	// Cascade after releasing the lock: TaskRepository.Create checks users
+	// while holding its own lock, so taking both here could deadlock
+	if r.messages != nil {
+		r.messages.clearUser(id)
+	}
+	if r.tasks != nil {
+		r.tasks.deleteByUser(id)
+	}
+	return nil
+}
+
+// exists reports whether a user with the given ID is stored
+func (r *UserRepository) exists(id int64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.users[id]
+	return ok
+}
+
+// emailTaken reports whether another user than except owns email
+func (r *UserRepository) emailTaken(email string, except int64) bool {
+	for id, u := range r.users {
+		if id != except && u.Email == email {
+			return true
+		}
+	}
+	return false
+}
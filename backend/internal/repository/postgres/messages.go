@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+const messageColumns = "id, COALESCE(user_id, 0), username, content, created_at"
+
+// MessageRepository is a repository.MessageRepository stored in the messages table
+type MessageRepository struct {
+	db DB
+}
+
+// NewMessageRepository creates a message repository using db
+func NewMessageRepository(db DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// Create inserts msg, filling in its ID and timestamp
+func (r *MessageRepository) Create(ctx context.Context, msg *models.Message) error {
+	err := r.db.QueryRow(ctx,
+		"INSERT INTO messages (user_id, username, content) VALUES (NULLIF($1, 0), $2, $3) RETURNING id, created_at",
+		msg.UserID, msg.Username, msg.Content,
+	).Scan(&msg.ID, &msg.Timestamp)
+	return mapError(err, repository.ErrMessageNotFound, repository.ErrConflict)
+}
+
+// GetByID returns the message with the given ID
+func (r *MessageRepository) GetByID(ctx context.Context, id int64) (*models.Message, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+messageColumns+" FROM messages WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := pgx.CollectExactlyOneRow(rows, scanMessage)
+	if err != nil {
+		return nil, mapError(err, repository.ErrMessageNotFound, repository.ErrConflict)
+	}
+	return msg, nil
+}
+
+// List returns messages ordered by ID
+func (r *MessageRepository) List(ctx context.Context, opts repository.ListOptions) ([]*models.Message, error) {
+	limit, offset := limitOffset(opts)
+	rows, err := r.db.Query(ctx, "SELECT "+messageColumns+" FROM messages ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanMessage)
+}
+
+// Update replaces the stored content
+func (r *MessageRepository) Update(ctx context.Context, msg *models.Message) error {
+	err := r.db.QueryRow(ctx,
+		"UPDATE messages SET content = $2 WHERE id = $1 RETURNING COALESCE(user_id, 0), username, created_at",
+		msg.ID, msg.Content,
+	).Scan(&msg.UserID, &msg.Username, &msg.Timestamp)
+	return mapError(err, repository.ErrMessageNotFound, repository.ErrConflict)
+}
+
+// Delete removes the message with the given ID
+func (r *MessageRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM messages WHERE id = $1", id)
+	return checkAffected(tag, err, repository.ErrMessageNotFound)
+}
+
+// Count returns the number of stored messages
+func (r *MessageRepository) Count(ctx context.Context) (int, error) {
+	var n int
+	err := r.db.QueryRow(ctx, "SELECT count(*) FROM messages").Scan(&n)
+	return n, err
+}
+
+func scanMessage(row pgx.CollectableRow) (*models.Message, error) {
+	var m models.Message
+	err := row.Scan(&m.ID, &m.UserID, &m.Username, &m.Content, &m.Timestamp)
+	return &m, err
+}
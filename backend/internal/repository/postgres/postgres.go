@@ -0,0 +1,88 @@
+// Package postgres implements the repository interfaces on a pgx connection
+// pool or transaction
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// SQLSTATE codes handled by the repositories
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// Connect opens a connection pool and verifies the database is reachable
+func Connect(ctx context.Context, url string) (*pgxpool.Pool, error) {
+	pool, err := pgxpool.New(ctx, url)
+	if err != nil {
+		return nil, err
+	}
+	if err := pool.Ping(ctx); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// DB runs the repositories' statements. It is satisfied by *pgxpool.Pool
+// and by pgx.Tx, so repositories built on a transaction write atomically.
+type DB interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// New returns repositories backed by db
+func New(db DB) *repository.Repositories {
+	return &repository.Repositories{
+		Users:    NewUserRepository(db),
+		Messages: NewMessageRepository(db),
+		Tasks:    NewTaskRepository(db),
+	}
+}
+
+// mapError translates driver errors into the given repository errors.
+// Every foreign key references users, so a violation means an unknown user.
+func mapError(err, notFound, conflict error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return notFound
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case uniqueViolation:
+			return conflict
+		case foreignKeyViolation:
+			return repository.ErrUnknownUser
+		}
+	}
+	return err
+}
+
+// limitOffset converts ListOptions into LIMIT and OFFSET arguments;
+// a NULL limit means no limit
+func limitOffset(opts repository.ListOptions) (limit *int, offset int) {
+	if opts.Limit > 0 {
+		limit = &opts.Limit
+	}
+	return limit, max(opts.Offset, 0)
+}
+
+// checkAffected reports notFound when a statement matched no row
+func checkAffected(tag pgconn.CommandTag, err, notFound error) error {
+	if err != nil {
+		return mapError(err, notFound, repository.ErrConflict)
+	}
+	if tag.RowsAffected() == 0 {
+		return notFound
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/migrate"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/postgres"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/repositorytest"
+)
+
+// TestConformance runs the shared suite against the database named by
+// TEST_DATABASE_URL. The database is migrated and its tables are emptied
+// before every test, so never point it at data you want to keep.
+func TestConformance(t *testing.T) {
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+
+	migrations, err := migrate.Load(os.DirFS("../../../migrations"))
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	driver, err := migrate.NewPostgres(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer driver.Close(ctx)
+	if err := migrate.New(driver, migrations, io.Discard).Up(ctx, 0); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	pool, err := postgres.Connect(ctx, url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	defer pool.Close()
+
+	repositorytest.Run(t, func(t *testing.T) *repository.Repositories {
+		if _, err := pool.Exec(ctx, "TRUNCATE users, messages, tasks RESTART IDENTITY CASCADE"); err != nil {
+			t.Fatalf("truncate: %v", err)
+		}
+		return postgres.New(pool)
+	})
+
+	t.Run("TransactionRollsBack", func(t *testing.T) {
+		tx, err := pool.Begin(ctx)
+		if err != nil {
+			t.Fatalf("begin: %v", err)
+		}
+		user := &models.User{Email: "rollback@example.com", PasswordHash: "hash"}
+		if err := postgres.New(tx).Users.Create(ctx, user); err != nil {
+			t.Fatalf("Create() in transaction error = %v", err)
+		}
+		if err := tx.Rollback(ctx); err != nil {
+			t.Fatalf("rollback: %v", err)
+		}
+		if _, err := postgres.New(pool).Users.GetByEmail(ctx, user.Email); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("GetByEmail() after rollback error = %v, want ErrUserNotFound", err)
+		}
+	})
+}
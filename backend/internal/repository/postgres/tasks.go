@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+const taskColumns = "id, user_id, title, description, done, created_at, updated_at"
+
+// TaskRepository is a repository.TaskRepository stored in the tasks table
+type TaskRepository struct {
+	db DB
+}
+
+// NewTaskRepository creates a task repository using db
+func NewTaskRepository(db DB) *TaskRepository {
+	return &TaskRepository{db: db}
+}
+
+// Create inserts task, filling in its ID and timestamps
+func (r *TaskRepository) Create(ctx context.Context, task *models.Task) error {
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO tasks (user_id, title, description, done) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at, updated_at`,
+		task.UserID, task.Title, task.Description, task.Done,
+	).Scan(&task.ID, &task.CreatedAt, &task.UpdatedAt)
+	return mapError(err, repository.ErrTaskNotFound, repository.ErrConflict)
+}
+
+// GetByID returns the task with the given ID
+func (r *TaskRepository) GetByID(ctx context.Context, id int64) (*models.Task, error) {
+	rows, err := r.db.Query(ctx, "SELECT "+taskColumns+" FROM tasks WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	task, err := pgx.CollectExactlyOneRow(rows, scanTask)
+	if err != nil {
+		return nil, mapError(err, repository.ErrTaskNotFound, repository.ErrConflict)
+	}
+	return task, nil
+}
+
+// List returns the tasks matching filter ordered by ID
+func (r *TaskRepository) List(ctx context.Context, filter repository.TaskFilter, opts repository.ListOptions) ([]*models.Task, error) {
+	limit, offset := limitOffset(opts)
+	var userID *int64
+	if filter.UserID != 0 {
+		userID = &filter.UserID
+	}
+	rows, err := r.db.Query(ctx,
+		`SELECT `+taskColumns+` FROM tasks
+		 WHERE ($1::BIGINT IS NULL OR user_id = $1) AND ($2::BOOLEAN IS NULL OR done = $2)
+		 ORDER BY id LIMIT $3 OFFSET $4`,
+		userID, filter.Done, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanTask)
+}
+
+// Update replaces the stored title, description and done flag
+func (r *TaskRepository) Update(ctx context.Context, task *models.Task) error {
+	err := r.db.QueryRow(ctx,
+		`UPDATE tasks SET title = $2, description = $3, done = $4, updated_at = now() WHERE id = $1
+		 RETURNING user_id, created_at, updated_at`,
+		task.ID, task.Title, task.Description, task.Done,
+	).Scan(&task.UserID, &task.CreatedAt, &task.UpdatedAt)
+	return mapError(err, repository.ErrTaskNotFound, repository.ErrConflict)
+}
+
+// Delete removes the task with the given ID
+func (r *TaskRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM tasks WHERE id = $1", id)
+	return checkAffected(tag, err, repository.ErrTaskNotFound)
+}
+
+func scanTask(row pgx.CollectableRow) (*models.Task, error) {
+	var t models.Task
+	err := row.Scan(&t.ID, &t.UserID, &t.Title, &t.Description, &t.Done, &t.CreatedAt, &t.UpdatedAt)
+	return &t, err
+}
@@ -0,0 +1,92 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+const userColumns = "id, email, password_hash, role, disabled, created_at"
+
+// UserRepository is a repository.UserRepository stored in the users table
+type UserRepository struct {
+	db DB
+}
+
+// NewUserRepository creates a user repository using db
+func NewUserRepository(db DB) *UserRepository {
+	return &UserRepository{db: db}
+}
+
+// Create inserts user, filling in its ID and creation time
+func (r *UserRepository) Create(ctx context.Context, user *models.User) error {
+	user.Email = strings.ToLower(user.Email)
+	if user.Role == "" {
+		user.Role = "user"
+	}
+	err := r.db.QueryRow(ctx,
+		`INSERT INTO users (email, password_hash, role, disabled) VALUES ($1, $2, $3, $4)
+		 RETURNING id, created_at`,
+		user.Email, user.PasswordHash, user.Role, user.Disabled,
+	).Scan(&user.ID, &user.CreatedAt)
+	return mapError(err, repository.ErrUserNotFound, repository.ErrEmailTaken)
+}
+
+// GetByID returns the user with the given ID
+func (r *UserRepository) GetByID(ctx context.Context, id int64) (*models.User, error) {
+	return r.get(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", id)
+}
+
+// GetByEmail returns the user with the given email, ignoring case
+func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	return r.get(ctx, "SELECT "+userColumns+" FROM users WHERE email = lower($1)", email)
+}
+
+// List returns users ordered by ID
+func (r *UserRepository) List(ctx context.Context, opts repository.ListOptions) ([]*models.User, error) {
+	limit, offset := limitOffset(opts)
+	rows, err := r.db.Query(ctx, "SELECT "+userColumns+" FROM users ORDER BY id LIMIT $1 OFFSET $2", limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, scanUser)
+}
+
+// Update replaces the stored email, password hash, role and disabled flag
+func (r *UserRepository) Update(ctx context.Context, user *models.User) error {
+	user.Email = strings.ToLower(user.Email)
+	err := r.db.QueryRow(ctx,
+		`UPDATE users SET email = $2, password_hash = $3, role = $4, disabled = $5 WHERE id = $1
+		 RETURNING created_at`,
+		user.ID, user.Email, user.PasswordHash, user.Role, user.Disabled,
+	).Scan(&user.CreatedAt)
+	return mapError(err, repository.ErrUserNotFound, repository.ErrEmailTaken)
+}
+
+// Delete removes the user with the given ID together with their tasks
+func (r *UserRepository) Delete(ctx context.Context, id int64) error {
+	tag, err := r.db.Exec(ctx, "DELETE FROM users WHERE id = $1", id)
+	return checkAffected(tag, err, repository.ErrUserNotFound)
+}
+
+func (r *UserRepository) get(ctx context.Context, query string, arg any) (*models.User, error) {
+	rows, err := r.db.Query(ctx, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	user, err := pgx.CollectExactlyOneRow(rows, scanUser)
+	if err != nil {
+		return nil, mapError(err, repository.ErrUserNotFound, repository.ErrEmailTaken)
+	}
+	return user, nil
+}
+
+func scanUser(row pgx.CollectableRow) (*models.User, error) {
+	var u models.User
+	err := row.Scan(&u.ID, &u.Email, &u.PasswordHash, &u.Role, &u.Disabled, &u.CreatedAt)
+	return &u, err
+}
@@ -0,0 +1,67 @@
+// Package publishing wraps repositories so that creating users and messages
+// and completing tasks publishes the matching domain events
+package publishing
+
+import (
+	"context"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/events"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// New wraps repos to publish on bus. Events are published only after the
+// write succeeded.
+func New(repos *repository.Repositories, bus *events.Bus) *repository.Repositories {
+	return &repository.Repositories{
+		Users:    &users{UserRepository: repos.Users, bus: bus},
+		Messages: &messages{MessageRepository: repos.Messages, bus: bus},
+		Tasks:    &tasks{TaskRepository: repos.Tasks, bus: bus},
+	}
+}
+
+type users struct {
+	repository.UserRepository
+	bus *events.Bus
+}
+
+func (r *users) Create(ctx context.Context, user *models.User) error {
+	if err := r.UserRepository.Create(ctx, user); err != nil {
+		return err
+	}
+	r.bus.Publish(ctx, events.UserRegistered{User: *user})
+	return nil
+}
+
+type messages struct {
+	repository.MessageRepository
+	bus *events.Bus
+}
+
+func (r *messages) Create(ctx context.Context, msg *models.Message) error {
+	if err := r.MessageRepository.Create(ctx, msg); err != nil {
+		return err
+	}
+	r.bus.Publish(ctx, events.MessageCreated{Message: *msg})
+	return nil
+}
+
+type tasks struct {
+	repository.TaskRepository
+	bus *events.Bus
+}
+
+// Update publishes TaskCompleted when the update marks an open task done
+func (r *tasks) Update(ctx context.Context, task *models.Task) error {
+	prev, err := r.TaskRepository.GetByID(ctx, task.ID)
+	if err != nil {
+		return err
+	}
+	if err := r.TaskRepository.Update(ctx, task); err != nil {
+		return err
+	}
+	if task.Done && !prev.Done {
+		r.bus.Publish(ctx, events.TaskCompleted{Task: *task})
+	}
+	return nil
+}
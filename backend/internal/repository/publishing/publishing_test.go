@@ -0,0 +1,97 @@
+package publishing
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/events"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/repositorytest"
+)
+
+func newBus() *events.Bus {
+	return events.New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestPublishingRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) *repository.Repositories {
+		return New(memory.New(), newBus())
+	})
+}
+
+func TestCreatePublishes(t *testing.T) {
+	ctx := context.Background()
+	bus := newBus()
+	var users []events.UserRegistered
+	var messages []events.MessageCreated
+	events.Subscribe(bus, "test", func(_ context.Context, e events.UserRegistered) error {
+		users = append(users, e)
+		return nil
+	})
+	events.Subscribe(bus, "test", func(_ context.Context, e events.MessageCreated) error {
+		messages = append(messages, e)
+		return nil
+	})
+	repos := New(memory.New(), bus)
+
+	if err := repos.Users.Create(ctx, &models.User{Email: "a@example.com", PasswordHash: "hash"}); err != nil {
+		t.Fatal(err)
+	}
+	// A failed write publishes nothing
+	if err := repos.Users.Create(ctx, &models.User{Email: "A@example.com", PasswordHash: "hash"}); err == nil {
+		t.Fatal("Expected a duplicate email to fail")
+	}
+	if err := repos.Messages.Create(ctx, &models.Message{Username: "alice", Content: "hi"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(users) != 1 || users[0].User.ID == 0 || users[0].User.Email != "a@example.com" {
+		t.Errorf("Expected one UserRegistered event with the stored user, got %+v", users)
+	}
+	if len(messages) != 1 || messages[0].Message.ID == 0 || messages[0].Message.Content != "hi" {
+		t.Errorf("Expected one MessageCreated event with the stored message, got %+v", messages)
+	}
+}
+
+func TestCompletingTaskPublishes(t *testing.T) {
+	ctx := context.Background()
+	bus := newBus()
+	var completed []events.TaskCompleted
+	events.Subscribe(bus, "test", func(_ context.Context, e events.TaskCompleted) error {
+		completed = append(completed, e)
+		return nil
+	})
+	repos := New(memory.New(), bus)
+
+	user := &models.User{Email: "a@example.com", PasswordHash: "hash"}
+	if err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	task := &models.Task{UserID: user.ID, Title: "Write tests"}
+	if err := repos.Tasks.Create(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	task.Title = "Write more tests"
+	if err := repos.Tasks.Update(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if len(completed) != 0 {
+		t.Fatalf("Expected no event for an edit that leaves the task open, got %+v", completed)
+	}
+
+	task.Done = true
+	if err := repos.Tasks.Update(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	// Saving a task that is already done does not complete it again
+	if err := repos.Tasks.Update(ctx, task); err != nil {
+		t.Fatal(err)
+	}
+	if len(completed) != 1 || completed[0].Task.ID != task.ID || !completed[0].Task.Done {
+		t.Errorf("Expected one TaskCompleted event for the task, got %+v", completed)
+	}
+}
@@ -0,0 +1,81 @@
+// Package repository defines the data-access interfaces of the backend.
+// Implementations live in the memory and postgres subpackages and must pass
+// the shared conformance suite in repositorytest.
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+)
+
+// Common errors. Repositories return the specific errors below, which wrap
+// these, so callers may test for either.
+var (
+	ErrNotFound         = errors.New("not found")
+	ErrConflict         = errors.New("already exists")
+	ErrInvalidReference = errors.New("does not exist")
+)
+
+// Domain errors
+var (
+	ErrUserNotFound    = fmt.Errorf("user %w", ErrNotFound)
+	ErrMessageNotFound = fmt.Errorf("message %w", ErrNotFound)
+	ErrTaskNotFound    = fmt.Errorf("task %w", ErrNotFound)
+	ErrEmailTaken      = fmt.Errorf("email %w", ErrConflict)
+	ErrUnknownUser     = fmt.Errorf("referenced user %w", ErrInvalidReference)
+)
+
+// ListOptions pages through results. A zero Limit returns everything.
+type ListOptions struct {
+	Limit  int
+	Offset int
+}
+
+// UserRepository stores user accounts. Emails are unique and case-insensitive.
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id int64) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	List(ctx context.Context, opts ListOptions) ([]*models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// MessageRepository stores chat messages, listed oldest first. A message
+// may have no author; creating one for a user that does not exist fails with
+// ErrUnknownUser, and deleting a user leaves their messages without one.
+type MessageRepository interface {
+	Create(ctx context.Context, msg *models.Message) error
+	GetByID(ctx context.Context, id int64) (*models.Message, error)
+	List(ctx context.Context, opts ListOptions) ([]*models.Message, error)
+	Update(ctx context.Context, msg *models.Message) error
+	Delete(ctx context.Context, id int64) error
+	Count(ctx context.Context) (int, error)
+}
+
+// TaskFilter narrows task listings
+type TaskFilter struct {
+	UserID int64
+	Done   *bool
+}
+
+// TaskRepository stores tasks, listed oldest first. Creating a task for a
+// user that does not exist fails with ErrUnknownUser, and deleting a user
+// deletes their tasks.
+type TaskRepository interface {
+	Create(ctx context.Context, task *models.Task) error
+	GetByID(ctx context.Context, id int64) (*models.Task, error)
+	List(ctx context.Context, filter TaskFilter, opts ListOptions) ([]*models.Task, error)
+	Update(ctx context.Context, task *models.Task) error
+	Delete(ctx context.Context, id int64) error
+}
+
+// Repositories bundles every repository of one backend
+type Repositories struct {
+	Users    UserRepository
+	Messages MessageRepository
+	Tasks    TaskRepository
+}
@@ -0,0 +1,393 @@
+// Package repositorytest is the conformance suite every repository backend
+// must pass. Backends call Run from their own tests.
+package repositorytest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+)
+
+// Factory returns empty repositories for a single test
+type Factory func(t *testing.T) *repository.Repositories
+
+// Run runs the whole suite against the backend created by newRepos
+func Run(t *testing.T, newRepos Factory) {
+	t.Run("Users", func(t *testing.T) { testUsers(t, newRepos) })
+	t.Run("Messages", func(t *testing.T) { testMessages(t, newRepos) })
+	t.Run("Tasks", func(t *testing.T) { testTasks(t, newRepos) })
+}
+
+func testUsers(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		users := newRepos(t).Users
+		u := &models.User{Email: "Alice@Example.com", PasswordHash: "hash"}
+		if err := users.Create(ctx, u); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if u.ID == 0 || u.CreatedAt.IsZero() {
+			t.Fatalf("Create() did not fill ID and CreatedAt: %+v", u)
+		}
+		if u.Email != "alice@example.com" || u.Role != "user" {
+			t.Errorf("Create() email, role = %q, %q; want lower-cased email and default role", u.Email, u.Role)
+		}
+
+		byID, err := users.GetByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if byID.Email != u.Email || byID.PasswordHash != "hash" || !byID.CreatedAt.Equal(u.CreatedAt) {
+			t.Errorf("GetByID() = %+v, want %+v", byID, u)
+		}
+
+		byEmail, err := users.GetByEmail(ctx, "ALICE@example.com")
+		if err != nil {
+			t.Fatalf("GetByEmail() error = %v", err)
+		}
+		if byEmail.ID != u.ID {
+			t.Errorf("GetByEmail() ID = %d, want %d", byEmail.ID, u.ID)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		users := newRepos(t).Users
+		mustCreateUser(t, users, "bob@example.com")
+		err := users.Create(ctx, &models.User{Email: "BOB@example.com", PasswordHash: "hash"})
+		if !errors.Is(err, repository.ErrEmailTaken) {
+			t.Errorf("Create() duplicate error = %v, want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		users := newRepos(t).Users
+		if _, err := users.GetByID(ctx, 404); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("GetByID() error = %v, want ErrUserNotFound", err)
+		}
+		if _, err := users.GetByEmail(ctx, "nobody@example.com"); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("GetByEmail() error = %v, want ErrUserNotFound", err)
+		}
+		if err := users.Update(ctx, &models.User{ID: 404, Email: "x@example.com"}); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("Update() error = %v, want ErrUserNotFound", err)
+		}
+		if err := users.Delete(ctx, 404); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("Delete() error = %v, want ErrUserNotFound", err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		users := newRepos(t).Users
+		u := mustCreateUser(t, users, "carol@example.com")
+		other := mustCreateUser(t, users, "dave@example.com")
+
+		u.Role = "admin"
+		u.Disabled = true
+		u.PasswordHash = "new-hash"
+		if err := users.Update(ctx, u); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		got, err := users.GetByID(ctx, u.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Role != "admin" || !got.Disabled || got.PasswordHash != "new-hash" {
+			t.Errorf("GetByID() after Update = %+v", got)
+		}
+
+		other.Email = "carol@example.com"
+		if err := users.Update(ctx, other); !errors.Is(err, repository.ErrEmailTaken) {
+			t.Errorf("Update() to taken email error = %v, want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("ListAndDelete", func(t *testing.T) {
+		users := newRepos(t).Users
+		var ids []int64
+		for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+			ids = append(ids, mustCreateUser(t, users, email).ID)
+		}
+
+		all, err := users.List(ctx, repository.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", userIDs(all), ids)
+
+		paged, err := users.List(ctx, repository.ListOptions{Limit: 1, Offset: 1})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List(limit 1, offset 1)", userIDs(paged), ids[1:2])
+
+		if err := users.Delete(ctx, ids[0]); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := users.GetByID(ctx, ids[0]); !errors.Is(err, repository.ErrUserNotFound) {
+			t.Errorf("GetByID() after Delete error = %v, want ErrUserNotFound", err)
+		}
+	})
+}
+
+func testMessages(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CRUD", func(t *testing.T) {
+		messages := newRepos(t).Messages
+		msg := &models.Message{Username: "alice", Content: "hello"}
+		if err := messages.Create(ctx, msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if msg.ID == 0 || msg.Timestamp.IsZero() {
+			t.Fatalf("Create() did not fill ID and Timestamp: %+v", msg)
+		}
+
+		got, err := messages.GetByID(ctx, msg.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.Username != "alice" || got.Content != "hello" || !got.Timestamp.Equal(msg.Timestamp) {
+			t.Errorf("GetByID() = %+v, want %+v", got, msg)
+		}
+
+		update := &models.Message{ID: msg.ID, Content: "edited"}
+		if err := messages.Update(ctx, update); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if update.Username != "alice" || update.Content != "edited" {
+			t.Errorf("Update() result = %+v", update)
+		}
+
+		if err := messages.Delete(ctx, msg.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := messages.GetByID(ctx, msg.ID); !errors.Is(err, repository.ErrMessageNotFound) {
+			t.Errorf("GetByID() after Delete error = %v, want ErrMessageNotFound", err)
+		}
+		if err := messages.Update(ctx, update); !errors.Is(err, repository.ErrMessageNotFound) {
+			t.Errorf("Update() after Delete error = %v, want ErrMessageNotFound", err)
+		}
+		if err := messages.Delete(ctx, msg.ID); !errors.Is(err, repository.ErrMessageNotFound) {
+			t.Errorf("Delete() twice error = %v, want ErrMessageNotFound", err)
+		}
+	})
+
+	t.Run("Author", func(t *testing.T) {
+		repos := newRepos(t)
+		alice := mustCreateUser(t, repos.Users, "alice@example.com")
+		msg := &models.Message{UserID: alice.ID, Username: "alice", Content: "hello"}
+		if err := repos.Messages.Create(ctx, msg); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if got, err := repos.Messages.GetByID(ctx, msg.ID); err != nil || got.UserID != alice.ID {
+			t.Fatalf("GetByID() = %+v, %v, want user ID %d", got, err, alice.ID)
+		}
+
+		orphan := &models.Message{UserID: 999, Username: "ghost", Content: "boo"}
+		if err := repos.Messages.Create(ctx, orphan); !errors.Is(err, repository.ErrUnknownUser) {
+			t.Errorf("Create() for an unknown user error = %v, want ErrUnknownUser", err)
+		}
+
+		if err := repos.Users.Delete(ctx, alice.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		got, err := repos.Messages.GetByID(ctx, msg.ID)
+		if err != nil {
+			t.Fatalf("GetByID() after deleting the author error = %v", err)
+		}
+		if got.UserID != 0 || got.Content != "hello" {
+			t.Errorf("GetByID() after deleting the author = %+v, want the message without a user ID", got)
+		}
+	})
+
+	t.Run("ListAndCount", func(t *testing.T) {
+		messages := newRepos(t).Messages
+		var ids []int64
+		for _, content := range []string{"one", "two", "three"} {
+			msg := &models.Message{Username: "bob", Content: content}
+			if err := messages.Create(ctx, msg); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			ids = append(ids, msg.ID)
+		}
+
+		all, err := messages.List(ctx, repository.ListOptions{})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List()", messageIDs(all), ids)
+
+		paged, err := messages.List(ctx, repository.ListOptions{Limit: 2, Offset: 2})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		assertIDs(t, "List(limit 2, offset 2)", messageIDs(paged), ids[2:])
+
+		if n, err := messages.Count(ctx); err != nil || n != 3 {
+			t.Errorf("Count() = %d, %v; want 3", n, err)
+		}
+	})
+}
+
+func testTasks(t *testing.T, newRepos Factory) {
+	ctx := context.Background()
+
+	t.Run("CRUD", func(t *testing.T) {
+		repos := newRepos(t)
+		owner := mustCreateUser(t, repos.Users, "owner@example.com")
+
+		task := &models.Task{UserID: owner.ID, Title: "write tests", Description: "for every backend"}
+		if err := repos.Tasks.Create(ctx, task); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		if task.ID == 0 || task.CreatedAt.IsZero() || task.UpdatedAt.IsZero() {
+			t.Fatalf("Create() did not fill ID and timestamps: %+v", task)
+		}
+
+		got, err := repos.Tasks.GetByID(ctx, task.ID)
+		if err != nil {
+			t.Fatalf("GetByID() error = %v", err)
+		}
+		if got.UserID != owner.ID || got.Title != task.Title || got.Description != task.Description || got.Done {
+			t.Errorf("GetByID() = %+v, want %+v", got, task)
+		}
+
+		update := &models.Task{ID: task.ID, Title: "write more tests", Done: true}
+		if err := repos.Tasks.Update(ctx, update); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if update.UserID != owner.ID || !update.Done || update.Description != "" || update.UpdatedAt.Before(task.UpdatedAt) {
+			t.Errorf("Update() result = %+v", update)
+		}
+
+		if err := repos.Tasks.Delete(ctx, task.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := repos.Tasks.GetByID(ctx, task.ID); !errors.Is(err, repository.ErrTaskNotFound) {
+			t.Errorf("GetByID() after Delete error = %v, want ErrTaskNotFound", err)
+		}
+		if err := repos.Tasks.Update(ctx, update); !errors.Is(err, repository.ErrTaskNotFound) {
+			t.Errorf("Update() after Delete error = %v, want ErrTaskNotFound", err)
+		}
+		if err := repos.Tasks.Delete(ctx, task.ID); !errors.Is(err, repository.ErrTaskNotFound) {
+			t.Errorf("Delete() twice error = %v, want ErrTaskNotFound", err)
+		}
+	})
+
+	t.Run("UnknownUser", func(t *testing.T) {
+		repos := newRepos(t)
+		task := &models.Task{UserID: 999, Title: "orphan"}
+		if err := repos.Tasks.Create(ctx, task); !errors.Is(err, repository.ErrUnknownUser) || !errors.Is(err, repository.ErrInvalidReference) {
+			t.Errorf("Create() for an unknown user error = %v, want ErrUnknownUser", err)
+		}
+	})
+
+	t.Run("DeletingUserDeletesTasks", func(t *testing.T) {
+		repos := newRepos(t)
+		alice := mustCreateUser(t, repos.Users, "alice@example.com")
+		bob := mustCreateUser(t, repos.Users, "bob@example.com")
+		gone := &models.Task{UserID: alice.ID, Title: "alice's"}
+		kept := &models.Task{UserID: bob.ID, Title: "bob's"}
+		for _, task := range []*models.Task{gone, kept} {
+			if err := repos.Tasks.Create(ctx, task); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+		}
+
+		if err := repos.Users.Delete(ctx, alice.ID); err != nil {
+			t.Fatalf("Delete() error = %v", err)
+		}
+		if _, err := repos.Tasks.GetByID(ctx, gone.ID); !errors.Is(err, repository.ErrTaskNotFound) {
+			t.Errorf("GetByID() of a deleted user's task error = %v, want ErrTaskNotFound", err)
+		}
+		if _, err := repos.Tasks.GetByID(ctx, kept.ID); err != nil {
+			t.Errorf("GetByID() of another user's task error = %v", err)
+		}
+	})
+
+	t.Run("ListFilters", func(t *testing.T) {
+		repos := newRepos(t)
+		alice := mustCreateUser(t, repos.Users, "alice@example.com")
+		bob := mustCreateUser(t, repos.Users, "bob@example.com")
+
+		create := func(userID int64, title string, done bool) int64 {
+			task := &models.Task{UserID: userID, Title: title, Done: done}
+			if err := repos.Tasks.Create(ctx, task); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			return task.ID
+		}
+		a1 := create(alice.ID, "a1", false)
+		a2 := create(alice.ID, "a2", true)
+		b1 := create(bob.ID, "b1", true)
+		a3 := create(alice.ID, "a3", false)
+
+		done, open := true, false
+		tests := []struct {
+			name   string
+			filter repository.TaskFilter
+			opts   repository.ListOptions
+			want   []int64
+		}{
+			{"all", repository.TaskFilter{}, repository.ListOptions{}, []int64{a1, a2, b1, a3}},
+			{"by user", repository.TaskFilter{UserID: alice.ID}, repository.ListOptions{}, []int64{a1, a2, a3}},
+			{"done", repository.TaskFilter{Done: &done}, repository.ListOptions{}, []int64{a2, b1}},
+			{"user and open", repository.TaskFilter{UserID: alice.ID, Done: &open}, repository.ListOptions{}, []int64{a1, a3}},
+			{"paged", repository.TaskFilter{UserID: alice.ID}, repository.ListOptions{Limit: 1, Offset: 1}, []int64{a2}},
+			{"past the end", repository.TaskFilter{}, repository.ListOptions{Offset: 10}, nil},
+		}
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				tasks, err := repos.Tasks.List(ctx, tt.filter, tt.opts)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+				ids := make([]int64, 0, len(tasks))
+				for _, task := range tasks {
+					ids = append(ids, task.ID)
+				}
+				assertIDs(t, "List()", ids, tt.want)
+			})
+		}
+	})
+}
+
+func mustCreateUser(t *testing.T, users repository.UserRepository, email string) *models.User {
+	t.Helper()
+	u := &models.User{Email: email, PasswordHash: "hash"}
+	if err := users.Create(context.Background(), u); err != nil {
+		t.Fatalf("Create(%s) error = %v", email, err)
+	}
+	return u
+}
+
+func userIDs(users []*models.User) []int64 {
+	ids := make([]int64, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.ID)
+	}
+	return ids
+}
+
+func messageIDs(messages []*models.Message) []int64 {
+	ids := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		ids = append(ids, m.ID)
+	}
+	return ids
+}
+
+func assertIDs(t *testing.T, what string, got, want []int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s IDs = %v, want %v", what, got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("%s IDs = %v, want %v", what, got, want)
+		}
+	}
+}
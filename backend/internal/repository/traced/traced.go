@@ -0,0 +1,173 @@
+// Package traced wraps repositories so every storage call is recorded as an
+// OpenTelemetry client span
+package traced
+
+import (
+	"context"
+	"errors"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/models"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName identifies the spans started by this package
+const tracerName = "github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/traced"
+
+// New wraps repos, tagging spans with the storage system, e.g. "postgresql"
+// or "memory". Spans use the global tracer provider.
+func New(repos *repository.Repositories, system string) *repository.Repositories {
+	db := semconv.DBSystemNameKey.String(system)
+	return &repository.Repositories{
+		Users:    &users{next: repos.Users, tracer: tracer{system: db, collection: "users"}},
+		Messages: &messages{next: repos.Messages, tracer: tracer{system: db, collection: "messages"}},
+		Tasks:    &tasks{next: repos.Tasks, tracer: tracer{system: db, collection: "tasks"}},
+	}
+}
+
+type tracer struct {
+	system     attribute.KeyValue
+	collection string
+}
+
+// start begins a span named "<operation> <collection>"
+func (t tracer) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return otel.Tracer(tracerName).Start(ctx, operation+" "+t.collection,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(t.system, semconv.DBCollectionName(t.collection), semconv.DBOperationName(operation)),
+	)
+}
+
+// end records err on span unless it is an expected not-found or conflict
+// outcome, then ends the span
+func end(span trace.Span, err error) {
+	if err != nil {
+		span.SetAttributes(semconv.ErrorType(err))
+		if !errors.Is(err, repository.ErrNotFound) && !errors.Is(err, repository.ErrConflict) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+type users struct {
+	next repository.UserRepository
+	tracer
+}
+
+func (r *users) Create(ctx context.Context, user *models.User) (err error) {
+	ctx, span := r.start(ctx, "create")
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, user)
+}
+
+func (r *users) GetByID(ctx context.Context, id int64) (_ *models.User, err error) {
+	ctx, span := r.start(ctx, "get")
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *users) GetByEmail(ctx context.Context, email string) (_ *models.User, err error) {
+	ctx, span := r.start(ctx, "get_by_email")
+	defer func() { end(span, err) }()
+	return r.next.GetByEmail(ctx, email)
+}
+
+func (r *users) List(ctx context.Context, opts repository.ListOptions) (_ []*models.User, err error) {
+	ctx, span := r.start(ctx, "list")
+	defer func() { end(span, err) }()
+	return r.next.List(ctx, opts)
+}
+
+func (r *users) Update(ctx context.Context, user *models.User) (err error) {
+	ctx, span := r.start(ctx, "update")
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, user)
+}
+
+func (r *users) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := r.start(ctx, "delete")
+	defer func() { end(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+type messages struct {
+	next repository.MessageRepository
+	tracer
+}
+
+func (r *messages) Create(ctx context.Context, msg *models.Message) (err error) {
+	ctx, span := r.start(ctx, "create")
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, msg)
+}
+
+func (r *messages) GetByID(ctx context.Context, id int64) (_ *models.Message, err error) {
+	ctx, span := r.start(ctx, "get")
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *messages) List(ctx context.Context, opts repository.ListOptions) (_ []*models.Message, err error) {
+	ctx, span := r.start(ctx, "list")
+	defer func() { end(span, err) }()
+	return r.next.List(ctx, opts)
+}
+
+func (r *messages) Update(ctx context.Context, msg *models.Message) (err error) {
+	ctx, span := r.start(ctx, "update")
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, msg)
+}
+
+func (r *messages) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := r.start(ctx, "delete")
+	defer func() { end(span, err) }()
+	return r.next.Delete(ctx, id)
+}
+
+func (r *messages) Count(ctx context.Context) (_ int, err error) {
+	ctx, span := r.start(ctx, "count")
+	defer func() { end(span, err) }()
+	return r.next.Count(ctx)
+}
+
+type tasks struct {
+	next repository.TaskRepository
+	tracer
+}
+
+func (r *tasks) Create(ctx context.Context, task *models.Task) (err error) {
+	ctx, span := r.start(ctx, "create")
+	defer func() { end(span, err) }()
+	return r.next.Create(ctx, task)
+}
+
+func (r *tasks) GetByID(ctx context.Context, id int64) (_ *models.Task, err error) {
+	ctx, span := r.start(ctx, "get")
+	defer func() { end(span, err) }()
+	return r.next.GetByID(ctx, id)
+}
+
+func (r *tasks) List(ctx context.Context, filter repository.TaskFilter, opts repository.ListOptions) (_ []*models.Task, err error) {
+	ctx, span := r.start(ctx, "list")
+	defer func() { end(span, err) }()
+	return r.next.List(ctx, filter, opts)
+}
+
+func (r *tasks) Update(ctx context.Context, task *models.Task) (err error) {
+	ctx, span := r.start(ctx, "update")
+	defer func() { end(span, err) }()
+	return r.next.Update(ctx, task)
+}
+
+func (r *tasks) Delete(ctx context.Context, id int64) (err error) {
+	ctx, span := r.start(ctx, "delete")
+	defer func() { end(span, err) }()
+	return r.next.Delete(ctx, id)
+}
@@ -0,0 +1,48 @@
+package traced
+
+import (
+	"context"
+	"testing"
+
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/memory"
+	"github.com/timur-harin/sum25-go-flutter-course/backend/internal/repository/repositorytest"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTracedRepositories(t *testing.T) {
+	repositorytest.Run(t, func(t *testing.T) *repository.Repositories {
+		return New(memory.New(), "memory")
+	})
+}
+
+func TestSpans(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	repos := New(memory.New(), "memory")
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "request")
+	if _, err := repos.Messages.GetByID(ctx, 42); err == nil {
+		t.Fatal("Expected a missing message")
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "get messages" || span.SpanKind() != trace.SpanKindClient {
+		t.Errorf("Expected client span get messages, got %s %v", span.Name(), span.SpanKind())
+	}
+	if span.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Error("Expected the storage span to be a child of the request span")
+	}
+	if span.Status().Code == codes.Error {
+		t.Error("Expected not found not to mark the span as failed")
+	}
+}
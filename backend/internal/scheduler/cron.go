@@ -0,0 +1,212 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidSchedule is wrapped by every cron parsing error
+var ErrInvalidSchedule = errors.New("invalid cron schedule")
+
+// descriptors are the shorthand schedules understood by Parse
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field describes one of the five cron fields
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minutes  = field{name: "minute", min: 0, max: 59}
+	hours    = field{name: "hour", min: 0, max: 23}
+	days     = field{name: "day of month", min: 1, max: 31}
+	months   = field{name: "month", min: 1, max: 12, names: monthNames}
+	weekdays = field{name: "day of week", min: 0, max: 7, names: dayNames}
+
+	monthNames = map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}
+	dayNames = map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}
+)
+
+// Schedule is a parsed cron expression
+type Schedule struct {
+	spec                          string
+	minute, hour, dom, month, dow uint64
+	domRestricted, dowRestricted  bool
+}
+
+// Parse reads a standard five-field cron expression
+//
+//	minute hour day-of-month month day-of-week
+//
+// Fields accept *, numbers, ranges (1-5), lists (1,15) and steps (*/10,
+// 0-30/5). Months and weekdays also accept three-letter English names and
+// Sunday may be written as 0 or 7. As in Vixie cron, when both day fields
+// are restricted a day matching either one is due. The descriptors @yearly,
+// @annually, @monthly, @weekly, @daily, @midnight and @hourly are accepted
+// too.
+func Parse(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if d, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = d
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%w %q: want 5 fields, got %d", ErrInvalidSchedule, spec, len(parts))
+	}
+
+	s := &Schedule{spec: spec}
+	var err error
+	for i, f := range []struct {
+		field
+		bits *uint64
+	}{
+		{minutes, &s.minute},
+		{hours, &s.hour},
+		{days, &s.dom},
+		{months, &s.month},
+		{weekdays, &s.dow},
+	} {
+		if *f.bits, err = f.parse(parts[i]); err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidSchedule, spec, err)
+		}
+	}
+	// Sunday is both 0 and 7
+	if s.dow&(1<<7) != 0 {
+		s.dow = s.dow&^(1<<7) | 1
+	}
+	s.domRestricted = !strings.HasPrefix(parts[2], "*")
+	s.dowRestricted = !strings.HasPrefix(parts[4], "*")
+	return s, nil
+}
+
+// parse turns one field into a bit set of the values it matches
+func (f field) parse(expr string) (uint64, error) {
+	var set uint64
+	for _, part := range strings.Split(expr, ",") {
+		rng, stepText, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("%s step %q must be a positive number", f.name, stepText)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			from, to, _ := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(from); err != nil {
+				return 0, err
+			}
+			if hi, err = f.value(to); err != nil {
+				return 0, err
+			}
+			if lo > hi {
+				return 0, fmt.Errorf("%s range %q is reversed", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			lo = v
+			// "5/15" means from 5 to the end in steps of 15
+			if !hasStep {
+				hi = v
+			}
+		}
+		for v := lo; v <= hi; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// value reads a number or name within the field's bounds
+func (f field) value(text string) (int, error) {
+	if v, ok := f.names[strings.ToLower(text)]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("%s %q must be between %d and %d", f.name, text, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t that matches the schedule in loc,
+// or the zero time when nothing matches within five years. Wall-clock times
+// skipped by a daylight saving change do not run that day, and times that
+// repeat when clocks go back run once.
+func (s *Schedule) Next(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	limit := t.AddDate(5, 0, 0)
+	next := t.Truncate(time.Minute).Add(time.Minute)
+
+	for next.Before(limit) {
+		y, m, d := next.Date()
+		h, mi := next.Hour(), next.Minute()
+		switch {
+		case s.month&(1<<uint(m)) == 0:
+			next = later(next, time.Date(y, m+1, 1, 0, 0, 0, 0, loc))
+		case !s.dayMatches(next):
+			next = later(next, time.Date(y, m, d+1, 0, 0, 0, 0, loc))
+		case s.hour&(1<<uint(h)) == 0:
+			next = next.Add(time.Duration(60-mi) * time.Minute)
+		case s.minute&(1<<uint(mi)) == 0:
+			next = next.Add(time.Minute)
+		case !time.Date(y, m, d, h, mi, 0, 0, loc).Equal(next):
+			// The second occurrence of a repeated wall-clock time; time.Date
+			// resolves to the first one, which has already matched
+			next = next.Add(time.Minute)
+		default:
+			return next
+		}
+	}
+	return time.Time{}
+}
+
+// later returns candidate, or the minute after next when a daylight saving
+// change made candidate fall at or before next
+func later(next, candidate time.Time) time.Time {
+	if candidate.After(next) {
+		return candidate
+	}
+	return next.Add(time.Minute)
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
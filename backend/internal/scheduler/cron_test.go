@@ -0,0 +1,103 @@
+package scheduler
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+		"1,,2 * * * *",
+		"@reboot",
+	} {
+		if _, err := Parse(spec); !errors.Is(err, ErrInvalidSchedule) {
+			t.Errorf("Parse(%q) error = %v, want ErrInvalidSchedule", spec, err)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		spec, from, want string
+	}{
+		{"* * * * *", "2025-03-10 12:00", "2025-03-10 12:01"},
+		{"*/15 * * * *", "2025-03-10 12:07", "2025-03-10 12:15"},
+		{"5/20 * * * *", "2025-03-10 12:26", "2025-03-10 12:45"},
+		{"0 3 * * *", "2025-03-10 03:00", "2025-03-11 03:00"},
+		{"30 9-17/4 * * *", "2025-03-10 13:31", "2025-03-10 17:30"},
+		{"0 0 1,15 * *", "2025-03-02 00:00", "2025-03-15 00:00"},
+		{"0 0 * * mon-fri", "2025-03-08 10:00", "2025-03-10 00:00"}, // Saturday to Monday
+		{"0 0 * * 7", "2025-03-10 00:00", "2025-03-16 00:00"},       // 7 is Sunday
+		{"0 0 13 * fri", "2025-03-10 00:00", "2025-03-13 00:00"},    // either day field matches
+		{"0 0 29 feb *", "2025-01-01 00:00", "2028-02-29 00:00"},
+		{"@monthly", "2025-12-31 23:59", "2026-01-01 00:00"},
+		{"@hourly", "2025-03-10 12:59", "2025-03-10 13:00"},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+		}
+		if got := s.Next(utc(tt.from), time.UTC); !got.Equal(utc(tt.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.spec, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+
+	never, _ := Parse("0 0 30 feb *")
+	if got := never.Next(utc("2025-01-01 00:00"), time.UTC); !got.IsZero() {
+		t.Errorf("impossible schedule Next() = %v, want zero time", got)
+	}
+}
+
+func TestNextTimezone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	daily, _ := Parse("30 9 * * *")
+
+	// 09:30 in New York is 14:30 UTC in winter and 13:30 UTC in summer
+	winter := daily.Next(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), ny)
+	if want := time.Date(2025, 1, 15, 14, 30, 0, 0, time.UTC); !winter.Equal(want) {
+		t.Errorf("winter Next() = %v, want %v", winter.UTC(), want)
+	}
+	summer := daily.Next(time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC), ny)
+	if want := time.Date(2025, 7, 15, 13, 30, 0, 0, time.UTC); !summer.Equal(want) {
+		t.Errorf("summer Next() = %v, want %v", summer.UTC(), want)
+	}
+
+	// 02:30 does not exist on 2025-03-09 in New York, so that day is skipped
+	skipped, _ := Parse("30 2 * * *")
+	got := skipped.Next(time.Date(2025, 3, 9, 0, 0, 0, 0, ny), ny)
+	if want := time.Date(2025, 3, 10, 2, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("Next() over spring-forward = %v, want %v", got, want)
+	}
+
+	// 01:30 happens twice on 2025-11-02 in New York but runs once
+	repeated, _ := Parse("30 1 * * *")
+	first := repeated.Next(time.Date(2025, 11, 2, 0, 0, 0, 0, ny), ny)
+	second := repeated.Next(first, ny)
+	if want := time.Date(2025, 11, 3, 1, 30, 0, 0, ny); !second.Equal(want) {
+		t.Errorf("Next() after %v = %v, want %v", first, second, want)
+	}
+}
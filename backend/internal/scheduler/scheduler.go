@@ -0,0 +1,320 @@
+// Package scheduler runs periodic tasks on cron schedules. A task never
+// overlaps itself: a run that comes due while the previous one is still
+// going is skipped and counted.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Errors returned by Add and Start
+var (
+	ErrDuplicateTask  = errors.New("scheduler: task already registered")
+	ErrAlreadyStarted = errors.New("scheduler: already started")
+)
+
+// Outcomes of a finished run
+const (
+	OutcomeSucceeded = "succeeded"
+	OutcomeFailed    = "failed"
+)
+
+// Clock tells the time and waits. Tests inject a fake one to control when
+// tasks come due.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// Task is a function run on a cron schedule
+type Task struct {
+	// Name identifies the task in logs and the status report
+	Name string
+	// Schedule is a cron expression understood by Parse
+	Schedule string
+	// Location overrides the scheduler's time zone for this task
+	Location *time.Location
+	// Timeout bounds a single run; zero means no limit
+	Timeout time.Duration
+	// Run does the work. Errors and panics are recorded as failed runs.
+	Run func(ctx context.Context) error
+}
+
+// Run describes one finished run of a task
+type Run struct {
+	Started    time.Time `json:"started"`
+	Finished   time.Time `json:"finished"`
+	DurationMs float64   `json:"duration_ms"`
+	Outcome    string    `json:"outcome"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Status reports a task's schedule and recent history
+type Status struct {
+	Name     string    `json:"name"`
+	Schedule string    `json:"schedule"`
+	Timezone string    `json:"timezone"`
+	Running  bool      `json:"running"`
+	NextRun  time.Time `json:"next_run,omitzero"`
+	LastRun  *Run      `json:"last_run,omitempty"`
+	Runs     int       `json:"runs"`
+	Failures int       `json:"failures"`
+	// Skipped counts runs not started because the previous one was still going
+	Skipped int `json:"skipped"`
+}
+
+// entry is a registered task and its state
+type entry struct {
+	task     Task
+	schedule *Schedule
+	loc      *time.Location
+	status   Status
+}
+
+// Scheduler starts tasks when their schedule comes due
+type Scheduler struct {
+	loc    *time.Location
+	logger *slog.Logger
+	clock  Clock
+
+	mu      sync.Mutex
+	entries []*entry
+	started bool
+	halted  bool
+
+	changed chan struct{}
+	stop    chan struct{}
+	stopped chan struct{}
+	running sync.WaitGroup
+	cancel  context.CancelFunc
+}
+
+// New creates a scheduler evaluating schedules in loc
+func New(loc *time.Location, logger *slog.Logger) *Scheduler {
+	return NewWithClock(loc, logger, realClock{})
+}
+
+// NewWithClock creates a scheduler driven by clock
+func NewWithClock(loc *time.Location, logger *slog.Logger, clock Clock) *Scheduler {
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &Scheduler{
+		loc:     loc,
+		logger:  logger,
+		clock:   clock,
+		changed: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+}
+
+// Add registers task. Tasks may be added before or after Start.
+func (s *Scheduler) Add(task Task) error {
+	schedule, err := Parse(task.Schedule)
+	if err != nil {
+		return err
+	}
+	loc := task.Location
+	if loc == nil {
+		loc = s.loc
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, e := range s.entries {
+		if e.task.Name == task.Name {
+			return fmt.Errorf("%w: %s", ErrDuplicateTask, task.Name)
+		}
+	}
+	s.entries = append(s.entries, &entry{
+		task:     task,
+		schedule: schedule,
+		loc:      loc,
+		status:   Status{Name: task.Name, Schedule: task.Schedule, Timezone: loc.String()},
+	})
+
+	// Wake a running loop so the new task's first run is scheduled
+	if s.started {
+		select {
+		case s.changed <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Start launches the scheduling loop. Tasks run until Stop is called.
+func (s *Scheduler) Start(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		return ErrAlreadyStarted
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	go s.loop(ctx)
+	return nil
+}
+
+// Stop stops starting runs and waits for running ones to finish. When ctx
+// ends first, running tasks are cancelled.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started || s.halted {
+		s.mu.Unlock()
+		return nil
+	}
+	s.halted = true
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.stopped
+
+	done := make(chan struct{})
+	go func() {
+		s.running.Wait()
+		close(done)
+	}()
+	defer s.cancel()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// loop sleeps until the earliest next run and starts every due task
+func (s *Scheduler) loop(ctx context.Context) {
+	defer close(s.stopped)
+	for {
+		now := s.clock.Now()
+		next := s.startDue(ctx, now)
+
+		var wake <-chan time.Time
+		if !next.IsZero() {
+			wake = s.clock.After(next.Sub(now))
+		}
+		select {
+		case <-s.stop:
+			return
+		case <-s.changed:
+		case <-wake:
+		}
+	}
+}
+
+// startDue starts the tasks due at now and returns the earliest next run
+func (s *Scheduler) startDue(ctx context.Context, now time.Time) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var earliest time.Time
+	for _, e := range s.entries {
+		if e.status.NextRun.IsZero() {
+			e.status.NextRun = e.schedule.Next(now, e.loc)
+		} else if !e.status.NextRun.After(now) {
+			if e.status.Running {
+				e.status.Skipped++
+				s.logger.Warn("scheduled task still running, skipping run", "task", e.task.Name)
+			} else {
+				e.status.Running = true
+				s.running.Add(1)
+				go s.run(ctx, e, now)
+			}
+			e.status.NextRun = e.schedule.Next(now, e.loc)
+		}
+		if next := e.status.NextRun; !next.IsZero() && (earliest.IsZero() || next.Before(earliest)) {
+			earliest = next
+		}
+	}
+	return earliest
+}
+
+// run executes one run of e and records its outcome
+func (s *Scheduler) run(ctx context.Context, e *entry, started time.Time) {
+	defer s.running.Done()
+	if e.task.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, e.task.Timeout)
+		defer cancel()
+	}
+
+	err := call(ctx, e.task.Run)
+	finished := s.clock.Now()
+	result := &Run{
+		Started:    started,
+		Finished:   finished,
+		DurationMs: float64(finished.Sub(started)) / float64(time.Millisecond),
+		Outcome:    OutcomeSucceeded,
+	}
+	if err != nil {
+		result.Outcome = OutcomeFailed
+		result.Error = err.Error()
+		s.logger.Error("scheduled task failed", "task", e.task.Name, "error", err)
+	} else {
+		s.logger.Info("scheduled task finished", "task", e.task.Name, "duration_ms", result.DurationMs)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.status.Running = false
+	e.status.LastRun = result
+	e.status.Runs++
+	if err != nil {
+		e.status.Failures++
+	}
+}
+
+// call runs fn, turning a panic into an error
+func call(ctx context.Context, fn func(context.Context) error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx)
+}
+
+// Status returns every task's status ordered by name
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		status := e.status
+		if status.LastRun != nil {
+			run := *status.LastRun
+			status.LastRun = &run
+		}
+		statuses = append(statuses, status)
+	}
+	slices.SortFunc(statuses, func(a, b Status) int { return strings.Compare(a.Name, b.Name) })
+	return statuses
+}
+
+// Handler serves the status of every task as JSON
+func (s *Scheduler) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tasks": s.Status()})
+	}
+}
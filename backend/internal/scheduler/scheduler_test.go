@@ -0,0 +1,267 @@
+package scheduler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	added   chan struct{}
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, added: make(chan struct{}, 100)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, waiter{at: c.now.Add(d), ch: ch})
+	c.added <- struct{}{}
+	return ch
+}
+
+// Advance moves the clock forward and fires the waiters now due
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	kept := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(c.now) {
+			kept = append(kept, w)
+			continue
+		}
+		w.ch <- c.now
+	}
+	c.waiters = kept
+}
+
+// waitForSleep blocks until the scheduler waits on the clock again
+func (c *fakeClock) waitForSleep(t *testing.T) {
+	t.Helper()
+	select {
+	case <-c.added:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scheduler did not wait on the clock")
+	}
+}
+
+func newTestScheduler(t *testing.T, clock Clock) *Scheduler {
+	t.Helper()
+	s := NewWithClock(time.UTC, slog.New(slog.NewTextHandler(io.Discard, nil)), clock)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.Stop(ctx); err != nil {
+			t.Errorf("Stop() error = %v", err)
+		}
+	})
+	return s
+}
+
+// waitForRuns polls until task name has finished n runs
+func waitForRuns(t *testing.T, s *Scheduler, name string, n int) Status {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		for _, st := range s.Status() {
+			if st.Name == name && st.Runs >= n && !st.Running {
+				return st
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task %s did not finish %d runs: %+v", name, n, s.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSchedulerRunsDueTasks(t *testing.T) {
+	start := time.Date(2025, 3, 10, 11, 59, 30, 0, time.UTC)
+	clock := newFakeClock(start)
+	s := newTestScheduler(t, clock)
+	runs := make(chan time.Time, 10)
+	s.Add(Task{Name: "cleanup", Schedule: "0 * * * *", Run: func(context.Context) error {
+		runs <- clock.Now()
+		return nil
+	}})
+	s.Start(context.Background())
+	clock.waitForSleep(t)
+
+	if st := s.Status()[0]; !st.NextRun.Equal(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)) || st.Runs != 0 {
+		t.Errorf("status before the first run = %+v", st)
+	}
+
+	clock.Advance(30 * time.Second)
+	if at := <-runs; !at.Equal(start.Add(30 * time.Second)) {
+		t.Errorf("task ran at %v, want 12:00", at)
+	}
+	st := waitForRuns(t, s, "cleanup", 1)
+	if st.LastRun.Outcome != OutcomeSucceeded || !st.NextRun.Equal(time.Date(2025, 3, 10, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("status after the first run = %+v, last run %+v", st, st.LastRun)
+	}
+}
+
+func TestSchedulerRecordsFailures(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	s := newTestScheduler(t, clock)
+	s.Add(Task{Name: "fails", Schedule: "* * * * *", Run: func(context.Context) error { return errors.New("disk full") }})
+	s.Add(Task{Name: "panics", Schedule: "* * * * *", Run: func(context.Context) error { panic("nil map") }})
+	s.Start(context.Background())
+	clock.waitForSleep(t)
+	clock.Advance(time.Minute)
+
+	for name, want := range map[string]string{"fails": "disk full", "panics": "panic: nil map"} {
+		st := waitForRuns(t, s, name, 1)
+		if st.Failures != 1 || st.LastRun.Outcome != OutcomeFailed || st.LastRun.Error != want {
+			t.Errorf("%s status = %+v, last run %+v; want a failure %q", name, st, st.LastRun, want)
+		}
+	}
+}
+
+func TestSchedulerPreventsOverlap(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	s := newTestScheduler(t, clock)
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	s.Add(Task{Name: "slow", Schedule: "* * * * *", Run: func(context.Context) error {
+		started <- struct{}{}
+		<-release
+		return nil
+	}})
+	s.Start(context.Background())
+	clock.waitForSleep(t)
+
+	clock.Advance(time.Minute)
+	<-started
+	clock.waitForSleep(t)
+	clock.Advance(time.Minute)
+	clock.waitForSleep(t)
+
+	st := s.Status()[0]
+	if !st.Running || st.Skipped != 1 {
+		t.Errorf("status while slow run continues = %+v, want running with 1 skipped", st)
+	}
+	select {
+	case <-started:
+		t.Error("a second run started while the first was running")
+	default:
+	}
+
+	close(release)
+	if st := waitForRuns(t, s, "slow", 1); st.Runs != 1 {
+		t.Errorf("runs = %d, want 1", st.Runs)
+	}
+}
+
+func TestSchedulerTimezone(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	clock := newFakeClock(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	s := newTestScheduler(t, clock)
+	s.Add(Task{Name: "digest", Schedule: "0 8 * * *", Location: ny, Run: func(context.Context) error { return nil }})
+	s.Add(Task{Name: "report", Schedule: "0 8 * * *", Run: func(context.Context) error { return nil }})
+	s.Start(context.Background())
+	clock.waitForSleep(t)
+
+	statuses := s.Status()
+	if st := statuses[0]; st.Timezone != "America/New_York" || !st.NextRun.Equal(time.Date(2025, 1, 15, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("digest status = %+v, want next run at 08:00 New York", st)
+	}
+	if st := statuses[1]; st.Timezone != "UTC" || !st.NextRun.Equal(time.Date(2025, 1, 15, 8, 0, 0, 0, time.UTC)) {
+		t.Errorf("report status = %+v, want next run at 08:00 UTC", st)
+	}
+}
+
+func TestSchedulerAdd(t *testing.T) {
+	s := newTestScheduler(t, newFakeClock(time.Now()))
+	noop := func(context.Context) error { return nil }
+	if err := s.Add(Task{Name: "a", Schedule: "bad", Run: noop}); !errors.Is(err, ErrInvalidSchedule) {
+		t.Errorf("Add(bad schedule) error = %v, want ErrInvalidSchedule", err)
+	}
+	if err := s.Add(Task{Name: "a", Schedule: "@daily", Run: noop}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := s.Add(Task{Name: "a", Schedule: "@hourly", Run: noop}); !errors.Is(err, ErrDuplicateTask) {
+		t.Errorf("Add(duplicate) error = %v, want ErrDuplicateTask", err)
+	}
+}
+
+func TestSchedulerStopWaitsForRuns(t *testing.T) {
+	clock := newFakeClock(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC))
+	s := NewWithClock(time.UTC, slog.New(slog.NewTextHandler(io.Discard, nil)), clock)
+	started := make(chan struct{})
+	s.Add(Task{Name: "cancellable", Schedule: "* * * * *", Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+	s.Start(context.Background())
+	clock.waitForSleep(t)
+	clock.Advance(time.Minute)
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := s.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want DeadlineExceeded", err)
+	}
+	st := waitForRuns(t, s, "cancellable", 1)
+	if st.LastRun.Error != context.Canceled.Error() {
+		t.Errorf("cancelled run error = %q, want %q", st.LastRun.Error, context.Canceled)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newTestScheduler(t, newFakeClock(time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)))
+	s.Add(Task{Name: "cleanup", Schedule: "@daily", Run: func(context.Context) error { return nil }})
+
+	router := gin.New()
+	router.GET("/scheduler", s.Handler())
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/scheduler", nil))
+
+	var body struct {
+		Tasks []Status `json:"tasks"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if w.Code != http.StatusOK || len(body.Tasks) != 1 || body.Tasks[0].Name != "cleanup" || body.Tasks[0].Schedule != "@daily" {
+		t.Errorf("GET /scheduler = %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,195 @@
+package tlsconfig
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for commonName and its key
+func writeCert(t *testing.T, dir, commonName string, modTime time.Time) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     []string{commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	files := map[string][]byte{
+		certFile: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyFile:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+	for path, data := range files {
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return certFile, keyFile
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+	cert, err := r.GetCertificate(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestReloaderPicksUpChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	certFile, keyFile := writeCert(t, dir, "old.example.com", start)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatalf("NewReloader() error = %v", err)
+	}
+	if got := commonName(t, r); got != "old.example.com" {
+		t.Fatalf("Expected old certificate, got %s", got)
+	}
+
+	if reloaded, err := r.Reload(); err != nil || reloaded {
+		t.Errorf("Expected unchanged files to be skipped, got %v, %v", reloaded, err)
+	}
+
+	writeCert(t, dir, "new.example.com", start.Add(time.Second))
+	if reloaded, err := r.Reload(); err != nil || !reloaded {
+		t.Fatalf("Expected reload, got %v, %v", reloaded, err)
+	}
+	if got := commonName(t, r); got != "new.example.com" {
+		t.Errorf("Expected new certificate, got %s", got)
+	}
+}
+
+func TestReloaderKeepsCertificateOnError(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Now().Add(-time.Minute)
+	certFile, keyFile := writeCert(t, dir, "good.example.com", start)
+
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(keyFile, []byte("not a key"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reload(); err == nil {
+		t.Fatal("Expected an error for a broken key file")
+	}
+	if got := commonName(t, r); got != "good.example.com" {
+		t.Errorf("Expected previous certificate to stay in use, got %s", got)
+	}
+
+	if _, err := NewReloader(filepath.Join(dir, "missing.pem"), keyFile); err == nil {
+		t.Error("Expected an error for a missing certificate")
+	}
+}
+
+func TestServesHTTP2(t *testing.T) {
+	certFile, keyFile := writeCert(t, t.TempDir(), "localhost", time.Now())
+	r, err := NewReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write([]byte(req.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.TLS = New(r, tls.VersionTLS12)
+	server.StartTLS()
+	defer server.Close()
+
+	client := server.Client()
+	client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.ProtoMajor != 2 {
+		t.Errorf("Expected HTTP/2, got %s", resp.Proto)
+	}
+
+	old := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		InsecureSkipVerify: true,
+		MaxVersion:         tls.VersionTLS11,
+	}}}
+	if _, err := old.Get(server.URL); err == nil {
+		t.Error("Expected TLS 1.1 to be refused")
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	if v, err := ParseVersion("1.3"); err != nil || v != tls.VersionTLS13 {
+		t.Errorf("ParseVersion(1.3) = %v, %v", v, err)
+	}
+	if _, err := ParseVersion("1.0"); err != ErrUnknownVersion {
+		t.Errorf("Expected ErrUnknownVersion, got %v", err)
+	}
+}
+
+func TestRedirectHandler(t *testing.T) {
+	tests := []struct {
+		port string
+		host string
+		want string
+	}{
+		{"443", "example.com", "https://example.com/api/v1/ping?x=1"},
+		{"443", "example.com:80", "https://example.com/api/v1/ping?x=1"},
+		{"8443", "example.com:8080", "https://example.com:8443/api/v1/ping?x=1"},
+		{"8443", "[::1]", "https://[::1]:8443/api/v1/ping?x=1"},
+		{"443", "[::1]", "https://[::1]/api/v1/ping?x=1"},
+		{"443", "[2001:db8::1]:80", "https://[2001:db8::1]/api/v1/ping?x=1"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/ping?x=1", nil)
+		req.Host = tt.host
+		w := httptest.NewRecorder()
+		RedirectHandler(tt.port).ServeHTTP(w, req)
+
+		if w.Code != http.StatusPermanentRedirect {
+			t.Errorf("Expected status 308, got %d", w.Code)
+		}
+		if got := w.Header().Get("Location"); got != tt.want {
+			t.Errorf("Host %s: expected redirect to %s, got %s", tt.host, tt.want, got)
+		}
+	}
+}
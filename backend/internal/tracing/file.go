@@ -0,0 +1,108 @@
+package tracing
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sync"
+
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// fileTransport answers OTLP/HTTP export requests by appending them to w as
+// one OTLP/JSON document per line. OTLP/JSON differs from the canonical
+// protobuf JSON mapping in encoding enums as numbers and IDs as hex.
+type fileTransport struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (t *fileTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	var export coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &export); err != nil {
+		return nil, err
+	}
+	line, err := marshalOTLP(&export)
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	_, err = t.w.Write(append(line, '\n'))
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := proto.Marshal(&coltracepb.ExportTraceServiceResponse{})
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"application/x-protobuf"}},
+		Body:          io.NopCloser(bytes.NewReader(reply)),
+		ContentLength: int64(len(reply)),
+		Request:       req,
+	}, nil
+}
+
+// idFields hold trace and span IDs, which OTLP/JSON encodes as hex
+var idFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+func marshalOTLP(export *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(export)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if err := hexIDs(doc); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
+
+// hexIDs rewrites the base64 IDs produced by protojson as hex
+func hexIDs(v any) error {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if s, ok := value.(string); ok && idFields[key] {
+				id, err := base64.StdEncoding.DecodeString(s)
+				if err != nil {
+					return err
+				}
+				v[key] = hex.EncodeToString(id)
+				continue
+			}
+			if err := hexIDs(value); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := hexIDs(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestOTLPFileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+	shutdown, err := Setup(context.Background(), Options{
+		ServiceName: "test-service",
+		Exporter:    ExporterOTLPFile,
+		File:        path,
+		SampleRatio: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, child := otel.Tracer("test").Start(ctx, "child")
+	child.End()
+	parent.End()
+
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("Expected clean shutdown, got %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	// OTLP/JSON as read by the OpenTelemetry Collector
+	var export struct {
+		ResourceSpans []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string `json:"key"`
+					Value struct {
+						StringValue string `json:"stringValue"`
+					} `json:"value"`
+				} `json:"attributes"`
+			} `json:"resource"`
+			ScopeSpans []struct {
+				Spans []struct {
+					TraceID string `json:"traceId"`
+					Name    string `json:"name"`
+					Kind    int    `json:"kind"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+
+	names := make(map[string]string)
+	var service string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &export); err != nil {
+			t.Fatalf("Expected JSON lines, got %v", err)
+		}
+		for _, rs := range export.ResourceSpans {
+			for _, attr := range rs.Resource.Attributes {
+				if attr.Key == "service.name" {
+					service = attr.Value.StringValue
+				}
+			}
+			for _, ss := range rs.ScopeSpans {
+				for _, span := range ss.Spans {
+					if span.Kind != int(trace.SpanKindInternal) {
+						t.Errorf("Expected numeric span kinds, got %d", span.Kind)
+					}
+					names[span.Name] = span.TraceID
+				}
+			}
+		}
+	}
+
+	if service != "test-service" {
+		t.Errorf("Expected service.name test-service, got %q", service)
+	}
+	if len(names) != 2 || names["parent"] != names["child"] || len(names["parent"]) != 32 {
+		t.Errorf("Expected parent and child spans in one trace, got %v", names)
+	}
+}
+
+func TestSetupInstallsTraceContextPropagator(t *testing.T) {
+	shutdown, err := Setup(context.Background(), Options{Exporter: ExporterNone, SampleRatio: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shutdown(context.Background())
+
+	carrier := propagation.MapCarrier{"traceparent": "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"}
+	ctx := otel.GetTextMapPropagator().Extract(context.Background(), carrier)
+	_, span := otel.Tracer("test").Start(ctx, "server")
+	defer span.End()
+
+	if got := span.SpanContext().TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected the incoming trace ID to be continued, got %s", got)
+	}
+}
+
+func TestSetupErrors(t *testing.T) {
+	if _, err := Setup(context.Background(), Options{Exporter: "jaeger"}); !errors.Is(err, ErrUnknownExporter) {
+		t.Errorf("Expected ErrUnknownExporter, got %v", err)
+	}
+	if _, err := Setup(context.Background(), Options{Exporter: ExporterOTLPFile}); !errors.Is(err, ErrNoFile) {
+		t.Errorf("Expected ErrNoFile, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Package version reports build metadata set at link time, for example:
+//
+//	go build -ldflags "-X .../internal/version.Version=1.2.3 -X .../internal/version.Commit=$(git rev-parse HEAD)"
+//
+// Values that are not set at build time fall back to runtime/debug.ReadBuildInfo.
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags "-X" at build time
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info is the build metadata of the running binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// String formats the info for --version output
+func (i Info) String() string {
+	commit := i.Commit
+	if i.Modified {
+		commit += "-dirty"
+	}
+	return fmt.Sprintf("%s (commit %s, built %s, %s)", i.Version, commit, i.BuildDate, i.GoVersion)
+}
+
+var (
+	once sync.Once
+	info Info
+)
+
+// Get returns the build metadata, computed once
+func Get() Info {
+	once.Do(func() {
+		bi, _ := debug.ReadBuildInfo()
+		info = resolve(bi)
+	})
+	return info
+}
+
+// resolve merges link-time values with the module build info
+func resolve(bi *debug.BuildInfo) Info {
+	i := Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildDate: BuildDate,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi != nil {
+		if i.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			i.Version = bi.Main.Version
+		}
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if i.Commit == "" {
+					i.Commit = s.Value
+				}
+			case "vcs.time":
+				if i.BuildDate == "" {
+					i.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				i.Modified = s.Value == "true"
+			}
+		}
+		if bi.GoVersion != "" {
+			i.GoVersion = bi.GoVersion
+		}
+	}
+
+	if i.Version == "" {
+		i.Version = "dev"
+	}
+	if i.Commit == "" {
+		i.Commit = "unknown"
+	}
+	if i.BuildDate == "" {
+		i.BuildDate = "unknown"
+	}
+	return i
+}
+
+// Requested reports whether args ask for the version with -version or --version
+func Requested(args []string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			return false
+		}
+		if arg == "-version" || arg == "--version" {
+			return true
+		}
+	}
+	return false
+}
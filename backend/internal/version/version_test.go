@@ -0,0 +1,64 @@
+package version
+
+import (
+	"runtime/debug"
+	"testing"
+)
+
+func TestResolveFallsBackToBuildInfo(t *testing.T) {
+	bi := &debug.BuildInfo{
+		GoVersion: "go1.24.3",
+		Main:      debug.Module{Version: "v1.4.0"},
+		Settings: []debug.BuildSetting{
+			{Key: "vcs.revision", Value: "abc123"},
+			{Key: "vcs.time", Value: "2025-06-01T10:00:00Z"},
+			{Key: "vcs.modified", Value: "true"},
+		},
+	}
+
+	got := resolve(bi)
+	want := Info{Version: "v1.4.0", Commit: "abc123", BuildDate: "2025-06-01T10:00:00Z", GoVersion: "go1.24.3", Modified: true}
+	if got != want {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+}
+
+func TestResolvePrefersLinkTimeValues(t *testing.T) {
+	Version, Commit, BuildDate = "1.2.3", "deadbeef", "2025-07-01"
+	defer func() { Version, Commit, BuildDate = "", "", "" }()
+
+	bi := &debug.BuildInfo{
+		Main:     debug.Module{Version: "(devel)"},
+		Settings: []debug.BuildSetting{{Key: "vcs.revision", Value: "abc123"}},
+	}
+
+	got := resolve(bi)
+	if got.Version != "1.2.3" || got.Commit != "deadbeef" || got.BuildDate != "2025-07-01" {
+		t.Errorf("Expected link-time values, got %+v", got)
+	}
+}
+
+func TestResolveDefaults(t *testing.T) {
+	got := resolve(nil)
+	if got.Version != "dev" || got.Commit != "unknown" || got.BuildDate != "unknown" || got.GoVersion == "" {
+		t.Errorf("Expected defaults, got %+v", got)
+	}
+}
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"--version"}, true},
+		{[]string{"up", "-version"}, true},
+		{[]string{"--port", "9000"}, false},
+		{[]string{"--", "--version"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := Requested(tt.args); got != tt.want {
+			t.Errorf("Requested(%v) = %v, expected %v", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,6 @@
+CREATE TABLE messages (
+    id         BIGSERIAL PRIMARY KEY,
+    username   TEXT        NOT NULL,
+    content    TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
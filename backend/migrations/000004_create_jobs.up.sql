@@ -0,0 +1,17 @@
+CREATE TABLE jobs (
+    id           BIGSERIAL PRIMARY KEY,
+    kind         TEXT        NOT NULL,
+    payload      JSONB       NOT NULL DEFAULT 'null',
+    state        TEXT        NOT NULL DEFAULT 'pending',
+    attempts     INTEGER     NOT NULL DEFAULT 0,
+    max_attempts INTEGER     NOT NULL,
+    run_at       TIMESTAMPTZ NOT NULL DEFAULT now(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT        NOT NULL DEFAULT '',
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX jobs_due_idx ON jobs (run_at) WHERE state = 'pending';
+CREATE INDEX jobs_lease_idx ON jobs (locked_until) WHERE state = 'running';
+CREATE INDEX jobs_state_idx ON jobs (state, id);
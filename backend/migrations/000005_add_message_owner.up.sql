@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN user_id BIGINT REFERENCES users (id) ON DELETE SET NULL;